@@ -0,0 +1,14 @@
+// Command myrient is the downloader's command line and daemon without the
+// GUI. It needs neither cgo nor a display, so it suits a NAS or a server;
+// the desktop build at the repository root runs the same commands too.
+package main
+
+import (
+	"os"
+
+	"awesomeProject1/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:]))
+}
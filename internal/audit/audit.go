@@ -0,0 +1,323 @@
+// internal/audit/audit.go
+package audit
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"awesomeProject1/internal/dat"
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/util"
+)
+
+// Status is the verdict for one game or file.
+type Status string
+
+const (
+	Have       Status = "have"       // present and, where checksums are known, correct
+	Unverified Status = "unverified" // present in an archive whose contents aren't checked
+	Miss       Status = "miss"       // expected but not found locally
+	Bad        Status = "bad"        // found but wrong size/CRC or incomplete
+	Extra      Status = "extra"      // local file nothing expects
+)
+
+// Item is one line of a report. Game is set when auditing against a DAT.
+type Item struct {
+	Name   string
+	Status Status
+	Path   string // local path, empty for Miss
+	Detail string // why an item is Bad or Unverified
+	Game   *dat.Game
+}
+
+// Report is the result of comparing a local folder against a source.
+type Report struct {
+	Dir    string
+	Source string
+	Items  []Item
+}
+
+// Count returns how many items have status s.
+func (r *Report) Count(s Status) int {
+	n := 0
+	for _, it := range r.Items {
+		if it.Status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// Filter returns the items with status s, or all items if s is empty.
+func (r *Report) Filter(s Status) []Item {
+	if s == "" {
+		return r.Items
+	}
+	var out []Item
+	for _, it := range r.Items {
+		if it.Status == s {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Summary is a one-line count of every status.
+func (r *Report) Summary() string {
+	return fmt.Sprintf(
+		"%d have, %d unverified, %d missing, %d bad, %d extra",
+		r.Count(Have), r.Count(Unverified), r.Count(Miss), r.Count(Bad), r.Count(Extra),
+	)
+}
+
+// localFile is one file found while scanning a folder.
+type localFile struct {
+	path string
+	name string // path relative to the scanned folder, slash separated
+	size int64
+}
+
+// scan lists the regular files under dir, skipping in-progress downloads.
+func scan(dir string) ([]localFile, error) {
+	var files []localFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".part") || strings.HasSuffix(path, ".part.json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, localFile{path: path, name: filepath.ToSlash(rel), size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// AgainstDAT audits dir against a DAT. A game counts as present either as
+// "<game>.zip" (checked from the zip's central directory, nothing is
+// extracted) or as loose ROM files, as left behind by automatic extraction.
+// A game kept as another archive, such as .7z or .rar, is present but
+// Unverified, since its contents can't be read.
+func AgainstDAT(dir string, idx *dat.Index) (*Report, error) {
+	files, err := scan(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := idx.Datafile()
+	rep := &Report{Dir: dir, Source: d.Header.Name}
+
+	type found struct {
+		item  Item
+		loose map[string]localFile // ROM name -> file
+	}
+	games := map[string]*found{}
+	claimed := map[string]bool{}
+
+	for _, f := range files {
+		base := filepath.Base(f.name)
+		g, ok := idx.GameFor(base)
+		if !ok {
+			continue
+		}
+		fd := games[g.Name]
+		if fd == nil {
+			fd = &found{loose: map[string]localFile{}}
+			games[g.Name] = fd
+		}
+		claimed[f.path] = true
+
+		if strings.EqualFold(filepath.Ext(base), ".zip") {
+			fd.item = Item{Name: g.Name, Path: f.path, Game: g}
+			if detail := checkZip(f.path, g); detail != "" {
+				fd.item.Status, fd.item.Detail = Bad, detail
+			} else {
+				fd.item.Status = Have
+			}
+			continue
+		}
+		if util.IsArchiveName(base) {
+			// A .zip of the same game is checked instead, if there is one.
+			if fd.item.Status == "" {
+				ext := strings.ToLower(filepath.Ext(base))
+				fd.item = Item{Name: g.Name, Status: Unverified, Path: f.path, Detail: ext + " contents not checked", Game: g}
+			}
+			continue
+		}
+		fd.loose[base] = f
+	}
+
+	for i := range d.Games {
+		g := &d.Games[i]
+		fd := games[g.Name]
+		switch {
+		case fd == nil:
+			rep.Items = append(rep.Items, Item{Name: g.Name, Status: Miss, Game: g})
+		case fd.item.Status == Unverified && len(fd.loose) > 0:
+			// Loose files can prove what the archive can't.
+			if it := checkLoose(dir, g, fd.loose); it.Status == Have {
+				rep.Items = append(rep.Items, it)
+			} else {
+				rep.Items = append(rep.Items, fd.item)
+			}
+		case fd.item.Status != "":
+			rep.Items = append(rep.Items, fd.item)
+		default:
+			rep.Items = append(rep.Items, checkLoose(dir, g, fd.loose))
+		}
+	}
+
+	rep.Items = append(rep.Items, extras(files, claimed)...)
+	return rep, nil
+}
+
+// AgainstListing audits dir against a remote listing by name. A listed
+// archive also counts as present when its extracted files are, i.e. a local
+// file with the same base name and a different extension exists.
+func AgainstListing(dir, source string, entries []domain.FileEntry) (*Report, error) {
+	files, err := scan(dir)
+	if err != nil {
+		return nil, err
+	}
+	rep := &Report{Dir: dir, Source: source}
+
+	byStem := map[string][]localFile{}
+	byName := map[string]localFile{}
+	for _, f := range files {
+		base := filepath.Base(f.name)
+		byName[base] = f
+		byStem[stem(base)] = append(byStem[stem(base)], f)
+	}
+
+	claimed := map[string]bool{}
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		if f, ok := byName[e.Name]; ok {
+			claimed[f.path] = true
+			rep.Items = append(rep.Items, Item{Name: e.Name, Status: Have, Path: f.path})
+			continue
+		}
+		if util.IsArchiveName(e.Name) {
+			if matches := byStem[stem(e.Name)]; len(matches) > 0 {
+				for _, f := range matches {
+					claimed[f.path] = true
+				}
+				rep.Items = append(rep.Items, Item{Name: e.Name, Status: Have, Path: matches[0].path})
+				continue
+			}
+		}
+		rep.Items = append(rep.Items, Item{Name: e.Name, Status: Miss})
+	}
+
+	rep.Items = append(rep.Items, extras(files, claimed)...)
+	return rep, nil
+}
+
+// checkZip compares a zip's members against the game's ROMs and returns
+// why it's bad, or "" if it's fine.
+func checkZip(path string, g *dat.Game) string {
+	members, err := util.ZipMembers(path)
+	if err != nil {
+		return err.Error()
+	}
+	byName := make(map[string]util.ZipMember, len(members))
+	for _, m := range members {
+		byName[m.Name] = m
+	}
+	for _, r := range g.ROMs {
+		if r.Status == "nodump" {
+			continue
+		}
+		m, ok := byName[r.Name]
+		if !ok {
+			return "missing " + r.Name
+		}
+		if r.Size > 0 && m.Size != r.Size {
+			return fmt.Sprintf("%s: size %d, expected %d", r.Name, m.Size, r.Size)
+		}
+		if r.CRC != "" && fmt.Sprintf("%08x", m.CRC32) != r.CRC {
+			return fmt.Sprintf("%s: CRC %08x, expected %s", r.Name, m.CRC32, r.CRC)
+		}
+	}
+	return ""
+}
+
+// checkLoose judges a game from its extracted ROM files. Sizes are compared
+// first so CRCs are only computed for files that could be right.
+func checkLoose(dir string, g *dat.Game, loose map[string]localFile) Item {
+	it := Item{Name: g.Name, Status: Have, Path: dir, Game: g}
+	for _, r := range g.ROMs {
+		if r.Status == "nodump" {
+			continue
+		}
+		f, ok := loose[r.Name]
+		if !ok {
+			return Item{Name: g.Name, Status: Bad, Path: dir, Detail: "missing " + r.Name, Game: g}
+		}
+		it.Path = f.path
+		if r.Size > 0 && f.size != r.Size {
+			return Item{Name: g.Name, Status: Bad, Path: f.path, Detail: fmt.Sprintf("%s: size %d, expected %d", r.Name, f.size, r.Size), Game: g}
+		}
+		if r.CRC == "" {
+			continue
+		}
+		crc, err := fileCRC32(f.path)
+		if err != nil {
+			return Item{Name: g.Name, Status: Bad, Path: f.path, Detail: err.Error(), Game: g}
+		}
+		if crc != r.CRC {
+			return Item{Name: g.Name, Status: Bad, Path: f.path, Detail: fmt.Sprintf("%s: CRC %s, expected %s", r.Name, crc, r.CRC), Game: g}
+		}
+	}
+	return it
+}
+
+func extras(files []localFile, claimed map[string]bool) []Item {
+	var out []Item
+	for _, f := range files {
+		if !claimed[f.path] {
+			out = append(out, Item{Name: f.name, Status: Extra, Path: f.path})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func fileCRC32(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return fmt.Sprintf("%08x", h.Sum32()), nil
+}
+
+func stem(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,50 @@
+// internal/audit/export.go
+package audit
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"awesomeProject1/internal/dat"
+)
+
+// WriteCSV writes one row per item: status, name, path, detail.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"status", "name", "path", "detail"}); err != nil {
+		return err
+	}
+	for _, it := range r.Items {
+		if err := cw.Write([]string{string(it.Status), it.Name, it.Path, it.Detail}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Fixdat returns a DAT of the games that are missing or bad, so a ROM
+// manager (or this tool's "Load DAT…") can fetch exactly those. Only
+// reports made against a DAT have games to put in it.
+func (r *Report) Fixdat() *dat.Datafile {
+	d := &dat.Datafile{
+		Header: dat.Header{
+			Name:        "fix_" + r.Source,
+			Description: "fix_" + r.Source + " (" + time.Now().Format("2006-01-02") + ")",
+			Version:     time.Now().Format("20060102"),
+			Author:      "myrient-downloader",
+		},
+	}
+	for _, it := range r.Items {
+		if it.Game != nil && (it.Status == Miss || it.Status == Bad) {
+			d.Games = append(d.Games, *it.Game)
+		}
+	}
+	return d
+}
+
+// WriteFixdat writes Fixdat as Logiqx XML.
+func (r *Report) WriteFixdat(w io.Writer) error {
+	return dat.WriteLogiqx(w, r.Fixdat())
+}
@@ -0,0 +1,239 @@
+// internal/cli/cli.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"awesomeProject1/internal/dat"
+	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/mirror"
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/sysmap"
+	"awesomeProject1/internal/util"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK          = 0   // everything succeeded
+	ExitFailed      = 1   // a listing or at least one download failed
+	ExitUsage       = 2   // bad command line
+	ExitInterrupted = 130 // stopped with Ctrl-C / SIGTERM
+)
+
+const usage = `Usage: myrient <command> [flags] [args]
+
+Commands:
+  ls    <url>             list a directory index
+  get   <url>...          download files, or every file in a directory URL
+  sync  <url> <dir>       keep dir in step with the tree under url
+  serve                   run as a daemon with an HTTP API for remote control
+  organize [dir]          rearrange a library for a frontend's layout profile
+  config                  show or create the settings file
+
+The desktop build starts the GUI when run without a command.
+Use "myrient <command> -h" for a command's flags. Flag defaults come from
+the settings file ($MYRIENT_CONFIG, or config.toml in the user config dir).
+`
+
+// command is one subcommand; run returns an exit code. conf holds the
+// settings file's values, which commands use as flag defaults.
+type command struct {
+	name string
+	run  func(ctx context.Context, conf settings.Settings, args []string) int
+}
+
+var commands = []command{
+	{"ls", runLs},
+	{"get", runGet},
+	{"sync", runSync},
+	{"serve", runServe},
+	{"organize", runOrganize},
+	{"config", runConfig},
+}
+
+// Run executes the command line in args (without the program name) and
+// returns the process exit code.
+func Run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return ExitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stderr, usage)
+		return ExitOK
+	}
+
+	conf := settings.Defaults()
+	if path, err := settings.DefaultPath(); err == nil {
+		if conf, err = settings.Load(path); err != nil {
+			return fail(err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			code := c.run(ctx, conf, args[1:])
+			if ctx.Err() != nil && code != ExitOK {
+				return ExitInterrupted
+			}
+			return code
+		}
+	}
+	fmt.Fprintf(os.Stderr, "myrient: unknown command %q\n\n%s", args[0], usage)
+	return ExitUsage
+}
+
+// newFlagSet returns a flag set that prints errors and help to stderr.
+func newFlagSet(name, args, summary string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: myrient %s [flags] %s\n\n%s\n\nFlags:\n", name, args, summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and reports the exit code to return if parsing
+// ended the command: help requested or bad flags.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK, true
+	}
+	if err != nil {
+		return ExitUsage, true
+	}
+	return 0, false
+}
+
+// transferFlags are the download settings shared by get, sync and serve.
+type transferFlags struct {
+	jobs        int
+	connections int
+	retries     int
+	limit       string
+	extract     bool
+	mirrors     string
+	checksums   string
+	datFile     string
+	verbose     bool
+}
+
+// register adds the flags to fs, defaulting to the values in conf.
+func (f *transferFlags) register(fs *flag.FlagSet, conf settings.Settings) {
+	fs.IntVar(&f.jobs, "jobs", conf.Concurrency, "files downloaded at once")
+	fs.IntVar(&f.connections, "connections", conf.Connections, "connections per file for large files")
+	fs.IntVar(&f.retries, "retries", conf.Retries, "attempts per file (0 = default policy)")
+	fs.StringVar(&f.limit, "limit", conf.RateLimit, "bandwidth limit per second, e.g. 500K or 2M")
+	fs.BoolVar(&f.extract, "extract", conf.Extract, "unpack downloaded .zip files and delete them")
+	fs.StringVar(&f.mirrors, "mirrors", strings.Join(conf.Mirrors, ","), "comma-separated base URLs of mirrors to fail over between")
+	fs.StringVar(&f.checksums, "checksums", "", "verify against an .sfv/.md5/.sha1 file")
+	fs.StringVar(&f.datFile, "dat", "", "verify against a Logiqx or ClrMamePro DAT")
+	fs.BoolVar(&f.verbose, "v", false, "log every step of each download")
+}
+
+// logger returns where download log lines go: out with -v, nowhere
+// otherwise.
+func (f *transferFlags) logger(out *terminal) download.Logger {
+	if f.verbose {
+		return out.Println
+	}
+	return nil
+}
+
+// manager builds a download.Manager from the flags. Log lines go to log if
+// it's set.
+func (f *transferFlags) manager(log download.Logger) (*download.Manager, error) {
+	var console *download.Console
+	if log != nil {
+		console = download.NewConsole(log)
+	}
+	mgr := download.NewManager(console)
+	mgr.SetSegments(f.connections)
+	mgr.SetExtract(f.extract)
+	if bases := splitList(f.mirrors); len(bases) > 0 {
+		mgr.SetMirrors(mirror.New(bases))
+	}
+
+	if f.limit != "" {
+		bps, ok := util.ParseSize(f.limit)
+		if !ok {
+			return nil, fmt.Errorf("invalid -limit %q", f.limit)
+		}
+		mgr.SetRateLimit(bps)
+	}
+
+	var sources download.ChecksumSources
+	if f.checksums != "" {
+		idx := download.NewChecksumIndex()
+		if _, err := idx.LoadChecksumFile(f.checksums); err != nil {
+			return nil, err
+		}
+		sources = append(sources, idx)
+	}
+	if f.datFile != "" {
+		d, err := dat.Load(f.datFile)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, download.DATSource(dat.NewIndex(d)))
+	}
+	if len(sources) > 0 {
+		mgr.SetChecksumSource(sources)
+	}
+	return mgr, nil
+}
+
+// outputDefaults returns the -to, -root and -profile defaults from conf:
+// the download folder, and the root URL and profile unless the layout is
+// flat.
+func outputDefaults(conf settings.Settings) (to, root, profile string) {
+	to = conf.DownloadDir
+	if to == "" {
+		to = "."
+	}
+	if conf.Layout == settings.LayoutSystem {
+		root, profile = conf.RootURL, conf.Profile
+	}
+	return to, root, profile
+}
+
+// folderRules returns conf's folder rules, or none for the flat layout.
+func folderRules(conf settings.Settings) []sysmap.Rule {
+	if conf.Layout != settings.LayoutSystem {
+		return nil
+	}
+	return conf.Rules
+}
+
+// profileUsage is the help text of -profile flags.
+var profileUsage = "lay files out for a frontend: " + strings.Join(sysmap.ProfileIDs(), ", ") + "; empty for none"
+
+// fail prints err and returns ExitFailed.
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "myrient:", err)
+	return ExitFailed
+}
+
+// isTerminal reports whether w is an interactive terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,54 @@
+// internal/cli/config.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"awesomeProject1/internal/settings"
+
+	"github.com/BurntSushi/toml"
+)
+
+func runConfig(ctx context.Context, conf settings.Settings, args []string) int {
+	flags := newFlagSet("config", "",
+		"Prints the settings file's path and the settings in effect. With -init,\n"+
+			"writes them to the file first if it doesn't exist yet, as a starting\n"+
+			"point for editing.")
+	var initFile bool
+	flags.BoolVar(&initFile, "init", false, "create the settings file if it doesn't exist")
+	if code, done := parseFlags(flags, args); done {
+		return code
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	path, err := settings.DefaultPath()
+	if err != nil {
+		return fail(err)
+	}
+	_, statErr := os.Stat(path)
+	exists := statErr == nil
+	if initFile && errors.Is(statErr, fs.ErrNotExist) {
+		if err := settings.Save(path, conf); err != nil {
+			return fail(err)
+		}
+		exists = true
+		fmt.Fprintln(os.Stderr, "Created", path)
+	}
+
+	if exists {
+		fmt.Printf("# %s\n", path)
+	} else {
+		fmt.Printf("# %s (not created yet; these are the defaults)\n", path)
+	}
+	if err := toml.NewEncoder(os.Stdout).Encode(conf); err != nil {
+		return fail(err)
+	}
+	return ExitOK
+}
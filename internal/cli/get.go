@@ -0,0 +1,114 @@
+// internal/cli/get.go
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/scraper"
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/sysmap"
+)
+
+func runGet(ctx context.Context, conf settings.Settings, args []string) int {
+	fs := newFlagSet("get", "<url>...",
+		"Downloads files. A directory URL (ending in /) downloads every file listed\n"+
+			"in it; with -r its subdirectories too, keeping their layout.")
+	var (
+		tf      transferFlags
+		wf      walkFlags
+		to      string
+		root    string
+		profile string
+	)
+	tf.register(fs, conf)
+	wf.register(fs, false)
+	toDefault, rootDefault, profileDefault := outputDefaults(conf)
+	fs.StringVar(&to, "to", toDefault, "folder to download into")
+	fs.StringVar(&root, "root", rootDefault, "sort files into <to>/<system> folders, detected from the URL below this root")
+	fs.StringVar(&profile, "profile", profileDefault, profileUsage)
+	if code, done := parseFlags(fs, args); done {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	out := newTerminal(os.Stderr)
+	mgr, err := tf.manager(tf.logger(out))
+	if err != nil {
+		return fail(err)
+	}
+
+	folders, err := sysmap.New(folderRules(conf), profile)
+	if err != nil {
+		return fail(err)
+	}
+	mgr.SetExtractRule(folders.Unzip)
+
+	// dirFor places a file under to, in the folder a rule or -profile names
+	// for it, or in its system folder if -root is set.
+	dirFor := func(fileURL, rel string) (string, error) {
+		if rel != "" && !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return "", fmt.Errorf("%s would land outside %s", fileURL, to)
+		}
+		dir := to
+		if folder := folders.Folder(root, fileURL); folder != "" {
+			dir = filepath.Join(dir, filepath.FromSlash(folder))
+		}
+		return filepath.Join(dir, filepath.FromSlash(rel)), nil
+	}
+
+	idx := scraper.NewHTTPIndex()
+	idx.SetMirrors(mgr.Mirrors())
+	var targets []target
+	listFailed := false
+	for _, u := range fs.Args() {
+		if !strings.HasSuffix(u, "/") {
+			name, err := url.PathUnescape(path.Base(u))
+			if err != nil {
+				name = path.Base(u)
+			}
+			dir, _ := dirFor(u, "")
+			targets = append(targets, target{name: name, url: u, dir: dir})
+			continue
+		}
+		err := idx.Walk(ctx, u, wf.options(), func(e scraper.WalkEntry, err error) error {
+			if err != nil {
+				listFailed = true
+				out.Println(fmt.Sprintf("FAILED    listing %s: %v", e.Path, err))
+				return nil
+			}
+			if !e.IsDir {
+				dir, err := dirFor(e.URL, path.Dir(e.Path))
+				if err != nil {
+					listFailed = true
+					out.Println("FAILED    " + err.Error())
+					return nil
+				}
+				targets = append(targets, target{name: e.Path, url: e.URL, dir: dir})
+			}
+			return nil
+		})
+		if err != nil {
+			return fail(fmt.Errorf("list %s: %w", u, err))
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to download.")
+		return ExitOK
+	}
+
+	res := runDownloads(ctx, out, mgr, tf, targets)
+	out.Println(res.summary())
+	if listFailed {
+		return ExitFailed
+	}
+	return res.exitCode()
+}
@@ -0,0 +1,103 @@
+// internal/cli/ls.go
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"awesomeProject1/internal/mirror"
+	"awesomeProject1/internal/scraper"
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/util"
+)
+
+// walkFlags select what is listed under a directory URL.
+type walkFlags struct {
+	recursive bool
+	depth     int
+	include   string
+	exclude   string
+}
+
+func (f *walkFlags) register(fs *flag.FlagSet, recursiveDefault bool) {
+	fs.BoolVar(&f.recursive, "r", recursiveDefault, "descend into subdirectories")
+	fs.IntVar(&f.depth, "depth", 0, "with -r, deepest level to descend to (0 = no limit)")
+	fs.StringVar(&f.include, "include", "", "comma-separated file name patterns to keep, e.g. '*.zip,*(USA)*'")
+	fs.StringVar(&f.exclude, "exclude", "", "comma-separated name patterns to skip, e.g. '*(Beta)*'")
+}
+
+func (f *walkFlags) options() scraper.WalkOptions {
+	opts := scraper.WalkOptions{
+		MaxDepth: 1,
+		Include:  splitList(f.include),
+		Exclude:  splitList(f.exclude),
+	}
+	if f.recursive {
+		opts.MaxDepth = f.depth
+	}
+	return opts
+}
+
+func runLs(ctx context.Context, conf settings.Settings, args []string) int {
+	fs := newFlagSet("ls", "<url>", "Lists a directory index with sizes and dates.")
+	var wf walkFlags
+	wf.register(fs, false)
+	if code, done := parseFlags(fs, args); done {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	idx := scraper.NewHTTPIndex()
+	if len(conf.Mirrors) > 0 {
+		idx.SetMirrors(mirror.New(conf.Mirrors))
+	}
+	files, dirs := 0, 0
+	var total int64
+	err := idx.Walk(ctx, fs.Arg(0), wf.options(), func(e scraper.WalkEntry, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "myrient: %s: %v\n", e.Path, err)
+			return nil
+		}
+		size, date := "-", "-"
+		if e.IsDir {
+			dirs++
+		} else {
+			files++
+			total += e.Size
+			if e.Size > 0 {
+				size = util.FormatBytes(e.Size, 1)
+			}
+		}
+		if !e.Modified.IsZero() {
+			date = e.Modified.Format("2006-01-02 15:04")
+		}
+		name := e.Path
+		if e.IsDir {
+			name += "/"
+		}
+		fmt.Printf("%10s  %16s  %s\n", size, date, name)
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "%d files (%s), %d directories\n", files, util.FormatBytes(total, 2), dirs)
+	return ExitOK
+}
+
+// splitList splits a comma-separated flag value.
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+// internal/cli/organize.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"awesomeProject1/internal/organize"
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/sysmap"
+)
+
+func runOrganize(ctx context.Context, conf settings.Settings, args []string) int {
+	fs := newFlagSet("organize", "[dir]",
+		"Moves the system folders of an existing library to where -profile wants\n"+
+			"them, and unpacks the archives it wants unpacked. Folders named after\n"+
+			"any profile, system ID or Myrient folder are recognised. dir defaults\n"+
+			"to the download folder.")
+	var (
+		profile string
+		dryRun  bool
+	)
+	fs.StringVar(&profile, "profile", conf.Profile, profileUsage)
+	fs.BoolVar(&dryRun, "n", false, "only print what would be done")
+	if code, done := parseFlags(fs, args); done {
+		return code
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+	dir, _, _ := outputDefaults(conf)
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	if profile == "" {
+		return fail(errors.New("no profile: pass -profile or set profile in the settings file"))
+	}
+	p, ok := sysmap.ProfileByID(profile)
+	if !ok {
+		return fail(fmt.Errorf("unknown profile %q (want one of %s)", profile, strings.Join(sysmap.ProfileIDs(), ", ")))
+	}
+
+	plan, err := organize.PlanLibrary(dir, p)
+	if err != nil {
+		return fail(err)
+	}
+	for _, line := range plan.Lines() {
+		fmt.Println(line)
+	}
+	out := newTerminal(os.Stderr)
+	out.Println(plan.Summary())
+	if dryRun || plan.Empty() {
+		return ExitOK
+	}
+	if err := plan.Apply(out.Println); err != nil {
+		return ExitFailed
+	}
+	return ExitOK
+}
@@ -0,0 +1,136 @@
+// internal/cli/run.go
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/util"
+)
+
+// target is one file to download and the folder it goes into.
+type target struct {
+	name string
+	url  string
+	dir  string
+}
+
+// runResult counts how a batch of downloads ended.
+type runResult struct {
+	done, failed, cancelled int
+	bytes                   int64
+
+	// errs holds why each target that didn't finish failed, by name.
+	errs map[string]error
+}
+
+// runDownloads downloads targets through a queue, printing a line per
+// finished file and a live status line, until all are done or ctx ends. On
+// cancellation running transfers stop and keep their .part files.
+func runDownloads(ctx context.Context, out *terminal, mgr *download.Manager, flags transferFlags, targets []target) runResult {
+	q := download.NewQueue(mgr, flags.jobs, flags.retries)
+
+	var mu sync.Mutex
+	res := runResult{errs: map[string]error{}}
+	reported := map[int]bool{}
+	q.OnUpdate = func(j *download.Job) {
+		st := j.State()
+		if !st.Finished() {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if reported[j.ID] {
+			return
+		}
+		reported[j.ID] = true
+
+		switch st {
+		case download.JobDone:
+			res.done++
+			res.bytes += j.Progress().BytesDone
+			out.Println("done      " + j.Name)
+		case download.JobFailed:
+			res.failed++
+			res.errs[j.Name] = j.Err()
+			out.Println(fmt.Sprintf("FAILED    %s: %v", j.Name, j.Err()))
+		case download.JobCancelled:
+			res.cancelled++
+			res.errs[j.Name] = context.Canceled
+		}
+	}
+
+	for _, t := range targets {
+		q.Add(t.name, t.url, t.dir)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		q.Wait()
+		close(finished)
+	}()
+
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case <-finished:
+			out.ClearStatus()
+			mu.Lock()
+			defer mu.Unlock()
+			return res
+		case <-ctx.Done():
+			q.CancelAll()
+			ctx = context.Background() // only cancel once
+		case <-tick.C:
+			out.SetStatus(statusLine(q))
+		}
+	}
+}
+
+// statusLine summarises the queue, e.g.
+// "[12/40] 3 running  1.2 GB / 4.0 GB  @ 8.5 MB/s".
+func statusLine(q *download.Queue) string {
+	var done, total, speed int64
+	jobs := q.Jobs()
+	for _, j := range jobs {
+		p := j.Progress()
+		done += p.BytesDone
+		total += p.BytesTotal
+		if j.State() == download.JobRunning {
+			speed += p.Speed
+		}
+	}
+	st := q.Stats()
+	line := fmt.Sprintf("[%d/%d] %d running  %s", st.Finished(), st.Total(), st.Running, util.FormatBytes(done, 1))
+	if total > 0 {
+		line += " / " + util.FormatBytes(total, 1)
+	}
+	if speed > 0 {
+		line += "  @ " + util.FormatBytes(speed, 1) + "/s"
+	}
+	return line
+}
+
+// exitCode maps a batch result to the process exit code.
+func (r runResult) exitCode() int {
+	if r.failed > 0 || r.cancelled > 0 {
+		return ExitFailed
+	}
+	return ExitOK
+}
+
+// summary is the closing line of a run.
+func (r runResult) summary() string {
+	s := fmt.Sprintf("%d downloaded (%s)", r.done, util.FormatBytes(r.bytes, 2))
+	if r.failed > 0 {
+		s += fmt.Sprintf(", %d failed", r.failed)
+	}
+	if r.cancelled > 0 {
+		s += fmt.Sprintf(", %d cancelled", r.cancelled)
+	}
+	return s
+}
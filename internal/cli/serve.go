@@ -0,0 +1,103 @@
+// internal/cli/serve.go
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"awesomeProject1/internal/daemon"
+	"awesomeProject1/internal/settings"
+)
+
+func runServe(ctx context.Context, conf settings.Settings, args []string) int {
+	fs := newFlagSet("serve", "",
+		"Runs as a daemon with an HTTP JSON API for listing indexes and driving\n"+
+			"the download queue from other machines, and a web UI for it at the\n"+
+			"same address. See the README for endpoints.")
+	var (
+		tf      transferFlags
+		addr    string
+		to      string
+		root    string
+		profile string
+		token   string
+		state   string
+	)
+	tf.register(fs, conf)
+	toDefault, rootDefault, profileDefault := outputDefaults(conf)
+	fs.StringVar(&addr, "listen", "127.0.0.1:8080", "address to serve the API on")
+	fs.StringVar(&to, "to", toDefault, "folder to download into")
+	fs.StringVar(&root, "root", rootDefault, "sort files into <to>/<system> folders, detected from the URL below this root")
+	fs.StringVar(&profile, "profile", profileDefault, profileUsage)
+	fs.StringVar(&token, "token", os.Getenv("MYRIENT_TOKEN"), "token clients must send (default $MYRIENT_TOKEN; empty = no auth)")
+	fs.StringVar(&state, "state", "", "job state file, so unfinished downloads survive a restart (default <to>/.myrient-jobs.json)")
+	if code, done := parseFlags(fs, args); done {
+		return code
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if token == "" && !isLoopback(addr) {
+		return fail(fmt.Errorf("refusing to listen on %s without a token: set -token or $MYRIENT_TOKEN, or listen on 127.0.0.1", addr))
+	}
+	if state == "" {
+		state = filepath.Join(to, ".myrient-jobs.json")
+	}
+
+	// The download log always feeds the web console; -v also prints it.
+	out := newTerminal(os.Stderr)
+	logs := daemon.NewLogBuffer(tf.logger(out))
+	mgr, err := tf.manager(logs.Add)
+	if err != nil {
+		return fail(err)
+	}
+	if tf.retries > 0 {
+		policy := mgr.RetryPolicy()
+		policy.MaxAttempts = tf.retries
+		mgr.SetRetryPolicy(policy)
+	}
+	srv, err := daemon.New(mgr, daemon.Config{
+		Dir:       to,
+		Root:      root,
+		Rules:     folderRules(conf),
+		Profile:   profile,
+		Token:     token,
+		Jobs:      tf.jobs,
+		StatePath: state,
+		Logs:      logs,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	if token == "" {
+		out.Println("Warning: no -token set, any program on this machine controls the downloader.")
+	}
+	out.Println(fmt.Sprintf("Serving the web UI and API on http://%s/ (Ctrl-C to stop).", addr))
+	err = srv.Serve(ctx, addr)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fail(err)
+	}
+	out.Println("Stopped; unfinished downloads resume on the next start.")
+	return ExitOK
+}
+
+// isLoopback reports whether addr only listens on this machine. An empty
+// host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,105 @@
+// internal/cli/sync.go
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"awesomeProject1/internal/scraper"
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/treesync"
+)
+
+func runSync(ctx context.Context, conf settings.Settings, args []string) int {
+	fs := newFlagSet("sync", "<url> <dir>",
+		"Keeps dir in step with the tree under url: downloads new files and\n"+
+			"files that changed upstream, judged by listed size and date. With\n"+
+			"-delete or -quarantine, files synced earlier that vanished upstream\n"+
+			"are removed too. Each run's changes are appended to\n"+
+			treesync.LogName+" in dir.")
+	var (
+		tf         transferFlags
+		wf         walkFlags
+		del        bool
+		quarantine string
+		dryRun     bool
+	)
+	tf.register(fs, conf)
+	wf.register(fs, true)
+	fs.BoolVar(&del, "delete", false, "delete local files that vanished upstream")
+	fs.StringVar(&quarantine, "quarantine", "", "move vanished and replaced files into this folder instead of deleting")
+	fs.BoolVar(&dryRun, "n", false, "only print what would change")
+	if code, done := parseFlags(fs, args); done {
+		return code
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return ExitUsage
+	}
+	remote, local := fs.Arg(0), fs.Arg(1)
+
+	out := newTerminal(os.Stderr)
+	mgr, err := tf.manager(tf.logger(out))
+	if err != nil {
+		return fail(err)
+	}
+
+	opts := wf.options()
+	out.SetStatus("Listing " + remote)
+	idx := scraper.NewHTTPIndex()
+	idx.SetMirrors(mgr.Mirrors())
+	files, partial, err := treesync.List(ctx, idx, remote, opts, func(p string, err error) {
+		out.Println(fmt.Sprintf("FAILED    listing %s: %v", p, err))
+	})
+	out.ClearStatus()
+	if err != nil {
+		return fail(fmt.Errorf("list %s: %w", remote, err))
+	}
+	plan, err := treesync.Compare(remote, local, files, partial, opts)
+	if err != nil {
+		return fail(err)
+	}
+	out.Println(plan.Summary() + ".")
+
+	if dryRun {
+		for _, it := range plan.Items {
+			if it.Action != treesync.Keep {
+				fmt.Printf("%-7s %s (%s)\n", it.Action, it.Path, it.Reason)
+			}
+		}
+		return ExitOK
+	}
+
+	plan.Prepare(treesync.Options{Delete: del, Quarantine: quarantine})
+	var targets []target
+	for _, it := range plan.Downloads() {
+		targets = append(targets, target{
+			name: it.Path,
+			url:  it.URL,
+			dir:  filepath.Join(local, filepath.FromSlash(path.Dir(it.Path))),
+		})
+	}
+
+	res := runDownloads(ctx, out, mgr, tf, targets)
+	if err := plan.Finish(func(p string) error { return res.errs[p] }); err != nil {
+		return fail(err)
+	}
+	out.Println(res.summary())
+	out.Println("Changes logged to " + filepath.Join(local, treesync.LogName))
+
+	// Download failures were printed as they happened; these weren't.
+	prepFailed := false
+	for _, it := range plan.Items {
+		if it.Err != nil && res.errs[it.Path] == nil {
+			prepFailed = true
+			out.Println(fmt.Sprintf("FAILED    %s %s: %v", it.Action, it.Path, it.Err))
+		}
+	}
+	if partial || prepFailed {
+		return ExitFailed
+	}
+	return res.exitCode()
+}
@@ -0,0 +1,59 @@
+// internal/cli/terminal.go
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// terminal writes log lines with a one-line status display underneath that
+// is redrawn in place. When the output isn't a terminal (cron, pipes) the
+// status is left out and only the log lines are written.
+type terminal struct {
+	mu     sync.Mutex
+	w      io.Writer
+	tty    bool
+	status string
+}
+
+func newTerminal(w io.Writer) *terminal {
+	return &terminal{w: w, tty: isTerminal(w)}
+}
+
+// Println writes msg on its own line above the status.
+func (t *terminal) Println(msg string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clearLocked()
+	fmt.Fprintln(t.w, msg)
+	if t.status != "" {
+		fmt.Fprint(t.w, t.status)
+	}
+}
+
+// SetStatus replaces the status line.
+func (t *terminal) SetStatus(s string) {
+	if !t.tty {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clearLocked()
+	t.status = s
+	fmt.Fprint(t.w, s)
+}
+
+// ClearStatus removes the status line for good.
+func (t *terminal) ClearStatus() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clearLocked()
+	t.status = ""
+}
+
+func (t *terminal) clearLocked() {
+	if t.tty && t.status != "" {
+		fmt.Fprint(t.w, "\r\033[K")
+	}
+}
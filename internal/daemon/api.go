@@ -0,0 +1,417 @@
+// internal/daemon/api.go
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/scraper"
+)
+
+// maxBody caps request bodies; the largest legitimate one is a URL list.
+const maxBody = 4 << 20
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/list", s.handleList)
+	s.mux.HandleFunc("GET /api/jobs", s.handleJobs)
+	s.mux.HandleFunc("POST /api/jobs", s.handleEnqueue)
+	s.mux.HandleFunc("GET /api/jobs/{id}", s.handleJob)
+	s.mux.HandleFunc("POST /api/jobs/{id}/{action}", s.handleJobAction)
+	s.mux.HandleFunc("POST /api/queue/{action}", s.handleQueueAction)
+	s.mux.HandleFunc("GET /api/settings", s.handleSettings)
+	s.mux.HandleFunc("PATCH /api/settings", s.handleUpdateSettings)
+	s.mux.HandleFunc("GET /api/status", s.handleStatus)
+	s.mux.HandleFunc("GET /api/log", s.handleLog)
+	s.mux.HandleFunc("GET /api/events", s.handleEvents)
+	s.mux.HandleFunc("POST /jsonrpc", s.handleRPC)
+	s.mux.HandleFunc("OPTIONS /jsonrpc", s.handleRPC)
+	s.mux.Handle("GET /", webHandler())
+}
+
+// entryJSON is a listed file or directory.
+type entryJSON struct {
+	Name     string     `json:"name"`
+	URL      string     `json:"url"`
+	Dir      bool       `json:"dir"`
+	Size     int64      `json:"size,omitempty"`
+	Modified *time.Time `json:"modified,omitempty"`
+}
+
+func newEntryJSON(fe domain.FileEntry) entryJSON {
+	e := entryJSON{Name: fe.Name, URL: fe.URL, Dir: fe.IsDir, Size: fe.Size}
+	if !fe.Modified.IsZero() {
+		e.Modified = &fe.Modified
+	}
+	return e
+}
+
+// jobJSON is a job with its progress.
+type jobJSON struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`
+	Dir        string     `json:"dir"`
+	State      string     `json:"state"`
+	BytesDone  int64      `json:"bytes_done"`
+	BytesTotal int64      `json:"bytes_total"`
+	Speed      int64      `json:"speed"`
+	ETA        string     `json:"eta,omitempty"`
+	Attempts   int        `json:"attempts"`
+	RateLimit  int64      `json:"rate_limit,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	NextRetry  *time.Time `json:"next_retry,omitempty"`
+}
+
+func newJobJSON(j *download.Job) jobJSON {
+	p := j.Progress()
+	out := jobJSON{
+		ID:         j.ID,
+		Name:       j.Name,
+		URL:        j.URL,
+		Dir:        j.TargetDir,
+		State:      j.State().String(),
+		BytesDone:  p.BytesDone,
+		BytesTotal: p.BytesTotal,
+		Speed:      p.Speed,
+		ETA:        p.ETA,
+		Attempts:   j.Attempts(),
+		RateLimit:  j.RateLimit(),
+	}
+	if err := j.Err(); err != nil {
+		out.Error = err.Error()
+	} else if !p.NextRetry.IsZero() && p.Err != nil && j.State() == download.JobRunning {
+		// Waiting to retry: Err is why the last attempt failed.
+		out.Error = p.Err.Error()
+		out.NextRetry = &p.NextRetry
+	}
+	return out
+}
+
+// settingsJSON are the queue settings that can be changed at runtime.
+type settingsJSON struct {
+	Concurrency int   `json:"concurrency"`
+	RateLimit   int64 `json:"rate_limit"` // bytes per second, 0 = unlimited
+	Connections int   `json:"connections"`
+}
+
+func (s *Server) settings() settingsJSON {
+	return settingsJSON{
+		Concurrency: s.queue.Concurrency(),
+		RateLimit:   s.mgr.RateLimit(),
+		Connections: max(s.mgr.Segments(), 1),
+	}
+}
+
+// GET /api/list?url=...[&recursive=1]
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	u := r.URL.Query().Get("url")
+	if u == "" {
+		writeError(w, http.StatusBadRequest, errors.New("url is required"))
+		return
+	}
+	if !isTrue(r.URL.Query().Get("recursive")) {
+		entries, err := s.idx.ListCtx(r.Context(), u)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		out := make([]entryJSON, 0, len(entries))
+		for _, fe := range entries {
+			out = append(out, newEntryJSON(fe))
+		}
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	out := []entryJSON{}
+	err := s.idx.Walk(r.Context(), u, scraper.WalkOptions{}, func(e scraper.WalkEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		ej := newEntryJSON(e.FileEntry)
+		ej.Name = e.Path
+		out = append(out, ej)
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// GET /api/jobs
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	jobs := s.queue.Jobs()
+	out := make([]jobJSON, 0, len(jobs))
+	for _, j := range jobs {
+		out = append(out, newJobJSON(j))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// enqueueRequest is the body of POST /api/jobs. A URL ending in "/" is a
+// directory: every file in it is queued, with Recursive its subdirectories
+// too, keeping their layout.
+type enqueueRequest struct {
+	URLs      []string `json:"urls"`
+	Dir       string   `json:"dir"` // subfolder of the download folder
+	Recursive bool     `json:"recursive"`
+	Include   []string `json:"include"`
+	Exclude   []string `json:"exclude"`
+}
+
+// POST /api/jobs
+func (s *Server) handleEnqueue(w http.ResponseWriter, r *http.Request) {
+	var req enqueueRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if len(req.URLs) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("urls is required"))
+		return
+	}
+	if req.Dir != "" && !filepath.IsLocal(req.Dir) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("dir %q must be a relative path inside the download folder", req.Dir))
+		return
+	}
+
+	opts := scraper.WalkOptions{MaxDepth: 1, Include: req.Include, Exclude: req.Exclude}
+	if req.Recursive {
+		opts.MaxDepth = 0
+	}
+	added := []jobJSON{}
+	for _, u := range req.URLs {
+		if !strings.HasSuffix(u, "/") {
+			name := path.Base(u)
+			if un, err := url.PathUnescape(name); err == nil {
+				name = un
+			}
+			dir, _ := s.targetDir(req.Dir, u, "")
+			added = append(added, newJobJSON(s.queue.Add(name, u, dir)))
+			continue
+		}
+		err := s.idx.Walk(r.Context(), u, opts, func(e scraper.WalkEntry, err error) error {
+			if err != nil || e.IsDir {
+				return nil
+			}
+			dir, err := s.targetDir(req.Dir, e.URL, path.Dir(e.Path))
+			if err != nil {
+				return nil
+			}
+			added = append(added, newJobJSON(s.queue.Add(e.Path, e.URL, dir)))
+			return nil
+		})
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Errorf("list %s: %w", u, err))
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, added)
+}
+
+// targetDir places a file in the download folder: below sub, in the folder
+// a rule names for it or its system folder when a root is configured, then
+// at rel. rel comes from the listing, so it's refused if it leads out.
+func (s *Server) targetDir(sub, fileURL, rel string) (string, error) {
+	if rel != "" && !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return "", fmt.Errorf("%s would land outside the download folder", fileURL)
+	}
+	dir := filepath.Join(s.cfg.Dir, sub)
+	if folder := s.folders.Folder(s.cfg.Root, fileURL); folder != "" {
+		dir = filepath.Join(dir, filepath.FromSlash(folder))
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel)), nil
+}
+
+// GET /api/jobs/{id}
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.job(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newJobJSON(j))
+}
+
+// POST /api/jobs/{id}/{pause|resume|cancel|retry|limit}. limit takes a
+// {"rate_limit": bytesPerSec} body.
+func (s *Server) handleJobAction(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.job(w, r)
+	if !ok {
+		return
+	}
+	switch r.PathValue("action") {
+	case "pause":
+		s.queue.Pause(j)
+	case "resume":
+		s.queue.Resume(j)
+	case "cancel":
+		s.queue.Cancel(j)
+	case "retry":
+		s.queue.Retry(j)
+	case "limit":
+		var body struct {
+			RateLimit int64 `json:"rate_limit"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		j.SetRateLimit(body.RateLimit)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown action %q", r.PathValue("action")))
+		return
+	}
+	writeJSON(w, http.StatusOK, newJobJSON(j))
+}
+
+func (s *Server) job(w http.ResponseWriter, r *http.Request) (*download.Job, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job id %q", r.PathValue("id")))
+		return nil, false
+	}
+	j := s.queue.Job(id)
+	if j == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no job %d", id))
+		return nil, false
+	}
+	return j, true
+}
+
+// POST /api/queue/{pause|resume|cancel|retry|clear}
+func (s *Server) handleQueueAction(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("action") {
+	case "pause":
+		s.queue.PauseAll()
+	case "resume":
+		s.queue.ResumeAll()
+	case "cancel":
+		s.queue.CancelAll()
+	case "retry":
+		for _, j := range s.queue.Jobs() {
+			if j.State() == download.JobFailed {
+				s.queue.Retry(j)
+			}
+		}
+	case "clear":
+		s.queue.ClearFinished()
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown action %q", r.PathValue("action")))
+		return
+	}
+	s.version.Add(1)
+	s.handleStatus(w, r)
+}
+
+// GET /api/settings
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.settings())
+}
+
+// PATCH /api/settings; fields left out keep their value.
+func (s *Server) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Concurrency *int   `json:"concurrency"`
+		RateLimit   *int64 `json:"rate_limit"`
+		Connections *int   `json:"connections"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Concurrency != nil {
+		s.queue.SetConcurrency(*body.Concurrency)
+	}
+	if body.RateLimit != nil {
+		s.mgr.SetRateLimit(max(*body.RateLimit, 0))
+	}
+	if body.Connections != nil {
+		s.mgr.SetSegments(*body.Connections)
+	}
+	s.version.Add(1)
+	writeJSON(w, http.StatusOK, s.settings())
+}
+
+// statusJSON summarises the queue.
+type statusJSON struct {
+	Queued    int          `json:"queued"`
+	Running   int          `json:"running"`
+	Paused    int          `json:"paused"`
+	Done      int          `json:"done"`
+	Failed    int          `json:"failed"`
+	Cancelled int          `json:"cancelled"`
+	Speed     int64        `json:"speed"`
+	Settings  settingsJSON `json:"settings"`
+}
+
+func (s *Server) status() statusJSON {
+	st := s.queue.Stats()
+	out := statusJSON{
+		Queued:    st.Queued,
+		Running:   st.Running,
+		Paused:    st.Paused,
+		Done:      st.Done,
+		Failed:    st.Failed,
+		Cancelled: st.Cancelled,
+		Settings:  s.settings(),
+	}
+	for _, j := range s.queue.Jobs() {
+		if j.State() == download.JobRunning {
+			out.Speed += j.Progress().Speed
+		}
+	}
+	return out
+}
+
+// GET /api/status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// readJSON decodes r's body into v, answering the request itself if it
+// can't. Only JSON bodies are taken: a page on another origin can post a form
+// or text/plain without asking, but not application/json.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if !isJSON(r) {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("body must be application/json"))
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return false
+	}
+	return true
+}
+
+// isJSON reports whether r says its body is JSON.
+func isJSON(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mt == "application/json"
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func isTrue(v string) bool {
+	b, _ := strconv.ParseBool(v)
+	return b
+}
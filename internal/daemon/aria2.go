@@ -0,0 +1,682 @@
+// internal/daemon/aria2.go
+package daemon
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/util"
+)
+
+// aria2Version is the aria2 release whose RPC interface this endpoint
+// follows. Clients such as AriaNg check it to decide which calls to make.
+const aria2Version = "1.36.0"
+
+// rpcRequest is one JSON-RPC 2.0 call.
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcMethod implements one aria2 call; p holds its parameters after the
+// secret token.
+type rpcMethod func(s *Server, p rpcParams) (any, error)
+
+var rpcMethods map[string]rpcMethod
+
+func init() {
+	// Assigned here rather than in the declaration because
+	// system.listMethods refers back to the table.
+	rpcMethods = map[string]rpcMethod{
+		"aria2.addUri":               (*Server).rpcAddURI,
+		"aria2.remove":               (*Server).rpcRemove,
+		"aria2.forceRemove":          (*Server).rpcRemove,
+		"aria2.pause":                (*Server).rpcPause,
+		"aria2.forcePause":           (*Server).rpcPause,
+		"aria2.unpause":              (*Server).rpcUnpause,
+		"aria2.pauseAll":             (*Server).rpcPauseAll,
+		"aria2.forcePauseAll":        (*Server).rpcPauseAll,
+		"aria2.unpauseAll":           (*Server).rpcUnpauseAll,
+		"aria2.tellStatus":           (*Server).rpcTellStatus,
+		"aria2.tellActive":           (*Server).rpcTellActive,
+		"aria2.tellWaiting":          (*Server).rpcTellWaiting,
+		"aria2.tellStopped":          (*Server).rpcTellStopped,
+		"aria2.getFiles":             (*Server).rpcGetFiles,
+		"aria2.getUris":              (*Server).rpcGetURIs,
+		"aria2.getOption":            (*Server).rpcGetOption,
+		"aria2.changeOption":         (*Server).rpcChangeOption,
+		"aria2.getGlobalOption":      (*Server).rpcGetGlobalOption,
+		"aria2.changeGlobalOption":   (*Server).rpcChangeGlobalOption,
+		"aria2.getGlobalStat":        (*Server).rpcGetGlobalStat,
+		"aria2.purgeDownloadResult":  (*Server).rpcPurgeDownloadResult,
+		"aria2.removeDownloadResult": (*Server).rpcRemoveDownloadResult,
+		"aria2.getVersion":           (*Server).rpcGetVersion,
+		"aria2.getSessionInfo":       (*Server).rpcGetSessionInfo,
+		"aria2.saveSession":          (*Server).rpcSaveSession,
+		"system.listMethods":         (*Server).rpcListMethods,
+	}
+}
+
+// errUnauthorized is what aria2 answers when the secret token is wrong.
+var errUnauthorized = errors.New("Unauthorized")
+
+// POST /jsonrpc speaks the subset of aria2's JSON-RPC interface that
+// download frontends use, so AriaNg or a browser's "send to aria2" button
+// can feed this queue. Files are sorted and extracted as any other job.
+func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	// Frontends like AriaNg run on another origin, as they do with aria2's
+	// --rpc-allow-origin-all. Only the secret token keeps other pages from
+	// driving the daemon then, so without one no origin is allowed.
+	if s.cfg.Token != "" {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if s.cfg.Token == "" && !isJSON(r) {
+		// Nor may they post a form or text/plain here, which needs no
+		// preflight; a token in the request guards against that instead.
+		writeJSON(w, http.StatusUnsupportedMediaType, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   &rpcError{Code: -32600, Message: "Invalid Request."},
+		})
+		return
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&raw); err != nil {
+		writeJSON(w, http.StatusBadRequest, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   &rpcError{Code: -32700, Message: "Parse error."},
+		})
+		return
+	}
+	headerOK := s.cfg.Token != "" && s.authorized(r)
+
+	if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(raw, &reqs); err != nil {
+			writeJSON(w, http.StatusBadRequest, rpcResponse{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &rpcError{Code: -32600, Message: "Invalid Request."},
+			})
+			return
+		}
+		out := make([]rpcResponse, len(reqs))
+		for i, req := range reqs {
+			out[i] = s.rpcCall(req, headerOK)
+		}
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   &rpcError{Code: -32600, Message: "Invalid Request."},
+		})
+		return
+	}
+	resp := s.rpcCall(req, headerOK)
+	code := http.StatusOK
+	if resp.Error != nil && resp.Error.Message == errUnauthorized.Error() {
+		code = http.StatusUnauthorized
+	}
+	writeJSON(w, code, resp)
+}
+
+// rpcCall runs one request. headerOK means the HTTP request already carried
+// the token, so the call needn't.
+func (s *Server) rpcCall(req rpcRequest, headerOK bool) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	result, err := s.rpcInvoke(req.Method, req.Params, headerOK)
+	if err != nil {
+		resp.Error = &rpcError{Code: 1, Message: err.Error()}
+		if errors.Is(err, errUnknownMethod) {
+			resp.Error.Code = -32601
+		}
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+var errUnknownMethod = errors.New("Method not found.")
+
+func (s *Server) rpcInvoke(method string, params []json.RawMessage, headerOK bool) (any, error) {
+	if method == "system.multicall" {
+		// Not in the table: it needs headerOK for the calls it makes.
+		return s.rpcMulticall(params, headerOK)
+	}
+	fn, ok := rpcMethods[method]
+	if !ok {
+		return nil, errUnknownMethod
+	}
+	p := rpcParams(params)
+	tok, rest := p.token()
+	if s.cfg.Token != "" && !headerOK && !strings.HasPrefix(method, "system.") &&
+		subtle.ConstantTimeCompare([]byte(tok), []byte(s.cfg.Token)) != 1 {
+		return nil, errUnauthorized
+	}
+	return fn(s, rest)
+}
+
+// rpcParams are positional call parameters.
+type rpcParams []json.RawMessage
+
+// token splits off a leading "token:<secret>" parameter.
+func (p rpcParams) token() (string, rpcParams) {
+	if len(p) == 0 {
+		return "", p
+	}
+	var first string
+	if json.Unmarshal(p[0], &first) == nil && strings.HasPrefix(first, "token:") {
+		return strings.TrimPrefix(first, "token:"), p[1:]
+	}
+	return "", p
+}
+
+// arg decodes parameter i into v, leaving v alone if it wasn't given.
+func (p rpcParams) arg(i int, v any) error {
+	if i >= len(p) {
+		return nil
+	}
+	if err := json.Unmarshal(p[i], v); err != nil {
+		return fmt.Errorf("parameter %d: %w", i+1, err)
+	}
+	return nil
+}
+
+// rpcJob decodes a GID parameter and finds its job.
+func (s *Server) rpcJob(p rpcParams, i int) (*download.Job, error) {
+	var gid string
+	if err := p.arg(i, &gid); err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(gid, 16, 64)
+	if err != nil || gid == "" {
+		return nil, fmt.Errorf("Bad GID %s", gid)
+	}
+	j := s.queue.Job(int(id))
+	if j == nil {
+		return nil, fmt.Errorf("GID %s is not found", gid)
+	}
+	return j, nil
+}
+
+// gid is aria2's 16-hex-digit download ID for j.
+func gid(j *download.Job) string {
+	return fmt.Sprintf("%016x", j.ID)
+}
+
+// aria2Options are option maps as aria2 sends them: every value a string.
+type aria2Options map[string]string
+
+// aria2.addUri([uris], {options}, position)
+func (s *Server) rpcAddURI(p rpcParams) (any, error) {
+	var uris []string
+	opts := aria2Options{}
+	if err := p.arg(0, &uris); err != nil {
+		return nil, err
+	}
+	if err := p.arg(1, &opts); err != nil {
+		return nil, err
+	}
+	if len(uris) == 0 {
+		return nil, errors.New("No URI to download.")
+	}
+	// Further URIs are mirrors of the same file in aria2; the mirror set
+	// configured here covers that.
+	u := uris[0]
+	name := path.Base(u)
+	if pu, err := url.Parse(u); err == nil {
+		name = path.Base(pu.Path)
+	}
+
+	dir, _ := s.targetDir("", u, "")
+	if d := opts["dir"]; d != "" {
+		var err error
+		if dir, err = s.rpcDir(d); err != nil {
+			return nil, err
+		}
+	}
+	limit, err := optionSize(opts, "max-download-limit")
+	if err != nil {
+		return nil, err
+	}
+	j := s.queue.Add(name, u, dir)
+	if limit > 0 {
+		j.SetRateLimit(limit)
+	}
+	return gid(j), nil
+}
+
+// rpcDir accepts a client's dir option if it's inside the download folder,
+// either relative to it or as an absolute path below it.
+func (s *Server) rpcDir(d string) (string, error) {
+	if !filepath.IsAbs(d) {
+		if !filepath.IsLocal(d) {
+			return "", fmt.Errorf("dir %s is outside the download folder", d)
+		}
+		return filepath.Join(s.cfg.Dir, d), nil
+	}
+	base, err := filepath.Abs(s.cfg.Dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(base, d)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return "", fmt.Errorf("dir %s is outside the download folder", d)
+	}
+	return d, nil
+}
+
+func optionSize(opts aria2Options, key string) (int64, error) {
+	v, ok := opts[key]
+	if !ok || v == "" {
+		return 0, nil
+	}
+	n, ok := util.ParseSize(v)
+	if !ok {
+		return 0, fmt.Errorf("%s: invalid value %q", key, v)
+	}
+	return n, nil
+}
+
+func (s *Server) rpcRemove(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.queue.Cancel(j)
+	return gid(j), nil
+}
+
+func (s *Server) rpcPause(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.queue.Pause(j)
+	return gid(j), nil
+}
+
+func (s *Server) rpcUnpause(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.queue.Resume(j)
+	return gid(j), nil
+}
+
+func (s *Server) rpcPauseAll(rpcParams) (any, error) {
+	s.queue.PauseAll()
+	return "OK", nil
+}
+
+func (s *Server) rpcUnpauseAll(rpcParams) (any, error) {
+	s.queue.ResumeAll()
+	return "OK", nil
+}
+
+// aria2Status maps a job state onto aria2's statuses.
+func aria2Status(st download.JobState) string {
+	switch st {
+	case download.JobRunning:
+		return "active"
+	case download.JobPaused:
+		return "paused"
+	case download.JobDone:
+		return "complete"
+	case download.JobFailed:
+		return "error"
+	case download.JobCancelled:
+		return "removed"
+	}
+	return "waiting"
+}
+
+// aria2Files describes the single file a job downloads.
+func aria2Files(j *download.Job) []map[string]any {
+	p := j.Progress()
+	name := path.Base(j.URL)
+	if u, err := url.Parse(j.URL); err == nil {
+		name = path.Base(u.Path)
+	}
+	return []map[string]any{{
+		"index":           "1",
+		"path":            filepath.Join(j.TargetDir, name),
+		"length":          strconv.FormatInt(p.BytesTotal, 10),
+		"completedLength": strconv.FormatInt(p.BytesDone, 10),
+		"selected":        "true",
+		"uris":            []map[string]string{{"uri": j.URL, "status": "used"}},
+	}}
+}
+
+// tell builds aria2's status struct for j, limited to keys if any are given.
+func tell(j *download.Job, keys []string) map[string]any {
+	p := j.Progress()
+	st := j.State()
+	connections := "0"
+	if st == download.JobRunning {
+		connections = "1"
+	}
+	m := map[string]any{
+		"gid":             gid(j),
+		"status":          aria2Status(st),
+		"totalLength":     strconv.FormatInt(p.BytesTotal, 10),
+		"completedLength": strconv.FormatInt(p.BytesDone, 10),
+		"uploadLength":    "0",
+		"downloadSpeed":   strconv.FormatInt(p.Speed, 10),
+		"uploadSpeed":     "0",
+		"connections":     connections,
+		"numPieces":       "1",
+		"pieceLength":     strconv.FormatInt(max(p.BytesTotal, 1), 10),
+		"dir":             j.TargetDir,
+		"files":           aria2Files(j),
+	}
+	if err := j.Err(); err != nil && st == download.JobFailed {
+		m["errorCode"] = "1"
+		m["errorMessage"] = err.Error()
+	} else if st.Finished() || st == download.JobRunning {
+		m["errorCode"] = "0"
+	}
+	if len(keys) == 0 {
+		return m
+	}
+	out := make(map[string]any, len(keys))
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func (s *Server) rpcTellStatus(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := p.arg(1, &keys); err != nil {
+		return nil, err
+	}
+	return tell(j, keys), nil
+}
+
+// tellWhere lists the jobs matching keep, as aria2's tell* calls do.
+func (s *Server) tellWhere(keep func(download.JobState) bool, keys []string) []map[string]any {
+	out := []map[string]any{}
+	for _, j := range s.queue.Jobs() {
+		if keep(j.State()) {
+			out = append(out, tell(j, keys))
+		}
+	}
+	return out
+}
+
+func (s *Server) rpcTellActive(p rpcParams) (any, error) {
+	var keys []string
+	if err := p.arg(0, &keys); err != nil {
+		return nil, err
+	}
+	return s.tellWhere(func(st download.JobState) bool { return st == download.JobRunning }, keys), nil
+}
+
+func (s *Server) rpcTellWaiting(p rpcParams) (any, error) {
+	return s.tellPage(p, func(st download.JobState) bool {
+		return st == download.JobQueued || st == download.JobPaused
+	})
+}
+
+func (s *Server) rpcTellStopped(p rpcParams) (any, error) {
+	return s.tellPage(p, download.JobState.Finished)
+}
+
+// tellPage implements the (offset, num, keys) calls. A negative offset
+// counts from the end.
+func (s *Server) tellPage(p rpcParams, keep func(download.JobState) bool) (any, error) {
+	var offset, num int
+	var keys []string
+	if err := p.arg(0, &offset); err != nil {
+		return nil, err
+	}
+	if err := p.arg(1, &num); err != nil {
+		return nil, err
+	}
+	if err := p.arg(2, &keys); err != nil {
+		return nil, err
+	}
+	all := s.tellWhere(keep, keys)
+	if offset < 0 {
+		offset = max(len(all)+offset, 0)
+	}
+	if offset > len(all) {
+		offset = len(all)
+	}
+	end := min(offset+max(num, 0), len(all))
+	return all[offset:end], nil
+}
+
+func (s *Server) rpcGetFiles(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	return aria2Files(j), nil
+}
+
+func (s *Server) rpcGetURIs(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	return []map[string]string{{"uri": j.URL, "status": "used"}}, nil
+}
+
+func (s *Server) rpcGetOption(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	return aria2Options{
+		"dir":                j.TargetDir,
+		"max-download-limit": strconv.FormatInt(j.RateLimit(), 10),
+	}, nil
+}
+
+func (s *Server) rpcChangeOption(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	opts := aria2Options{}
+	if err := p.arg(1, &opts); err != nil {
+		return nil, err
+	}
+	if _, ok := opts["max-download-limit"]; ok {
+		limit, err := optionSize(opts, "max-download-limit")
+		if err != nil {
+			return nil, err
+		}
+		j.SetRateLimit(limit)
+	}
+	return "OK", nil
+}
+
+func (s *Server) rpcGetGlobalOption(rpcParams) (any, error) {
+	set := s.settings()
+	return aria2Options{
+		"dir":                        s.cfg.Dir,
+		"max-concurrent-downloads":   strconv.Itoa(set.Concurrency),
+		"max-overall-download-limit": strconv.FormatInt(set.RateLimit, 10),
+		"split":                      strconv.Itoa(set.Connections),
+		"max-connection-per-server":  strconv.Itoa(set.Connections),
+	}, nil
+}
+
+// aria2.changeGlobalOption understands the concurrency, bandwidth and
+// connection options; others are ignored, as aria2 ignores options that
+// can't change at runtime.
+func (s *Server) rpcChangeGlobalOption(p rpcParams) (any, error) {
+	opts := aria2Options{}
+	if err := p.arg(0, &opts); err != nil {
+		return nil, err
+	}
+	if v, ok := opts["max-concurrent-downloads"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("max-concurrent-downloads: invalid value %q", v)
+		}
+		s.queue.SetConcurrency(n)
+	}
+	if _, ok := opts["max-overall-download-limit"]; ok {
+		limit, err := optionSize(opts, "max-overall-download-limit")
+		if err != nil {
+			return nil, err
+		}
+		s.mgr.SetRateLimit(limit)
+	}
+	for _, k := range []string{"split", "max-connection-per-server"} {
+		if v, ok := opts[k]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid value %q", k, v)
+			}
+			s.mgr.SetSegments(n)
+		}
+	}
+	s.version.Add(1)
+	return "OK", nil
+}
+
+func (s *Server) rpcGetGlobalStat(rpcParams) (any, error) {
+	st := s.status()
+	stopped := strconv.Itoa(st.Done + st.Failed + st.Cancelled)
+	return map[string]string{
+		"downloadSpeed":   strconv.FormatInt(st.Speed, 10),
+		"uploadSpeed":     "0",
+		"numActive":       strconv.Itoa(st.Running),
+		"numWaiting":      strconv.Itoa(st.Queued + st.Paused),
+		"numStopped":      stopped,
+		"numStoppedTotal": stopped,
+	}, nil
+}
+
+func (s *Server) rpcPurgeDownloadResult(rpcParams) (any, error) {
+	s.queue.ClearFinished()
+	s.version.Add(1)
+	return "OK", nil
+}
+
+func (s *Server) rpcRemoveDownloadResult(p rpcParams) (any, error) {
+	j, err := s.rpcJob(p, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !s.queue.Remove(j) {
+		return nil, fmt.Errorf("Could not remove download result of GID#%s", gid(j))
+	}
+	s.version.Add(1)
+	return "OK", nil
+}
+
+func (s *Server) rpcGetVersion(rpcParams) (any, error) {
+	return map[string]any{
+		"version":         aria2Version,
+		"enabledFeatures": []string{"HTTPS"},
+	}, nil
+}
+
+func (s *Server) rpcGetSessionInfo(rpcParams) (any, error) {
+	return map[string]string{"sessionId": s.sessionID}, nil
+}
+
+func (s *Server) rpcSaveSession(rpcParams) (any, error) {
+	if s.store != nil {
+		if err := s.store.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	return "OK", nil
+}
+
+// system.multicall([{methodName, params}, ...]) returns, per call, either
+// [result] or an error struct.
+func (s *Server) rpcMulticall(p rpcParams, headerOK bool) (any, error) {
+	var calls []struct {
+		MethodName string            `json:"methodName"`
+		Params     []json.RawMessage `json:"params"`
+	}
+	if err := p.arg(0, &calls); err != nil {
+		return nil, err
+	}
+	out := make([]any, len(calls))
+	for i, c := range calls {
+		if c.MethodName == "system.multicall" {
+			out[i] = rpcError{Code: 1, Message: "Recursive system.multicall forbidden."}
+			continue
+		}
+		res, err := s.rpcInvoke(c.MethodName, c.Params, headerOK)
+		if err != nil {
+			out[i] = rpcError{Code: 1, Message: err.Error()}
+			continue
+		}
+		out[i] = []any{res}
+	}
+	return out, nil
+}
+
+func (s *Server) rpcListMethods(rpcParams) (any, error) {
+	names := []string{"system.multicall"}
+	for name := range rpcMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// newSessionID returns a random ID for aria2.getSessionInfo.
+func newSessionID() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
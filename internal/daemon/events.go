@@ -0,0 +1,155 @@
+// internal/daemon/events.go
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// logLines is how many log lines the web console can scroll back.
+	logLines = 500
+
+	// eventInterval is how often the event stream checks for changes. Job
+	// progress changes with every chunk, so pushing each one would flood
+	// slow clients; a few snapshots a second is plenty for a progress bar.
+	eventInterval = 300 * time.Millisecond
+
+	// keepAliveInterval is how often an idle stream sends a comment so
+	// proxies don't close it.
+	keepAliveInterval = 20 * time.Second
+)
+
+// LogBuffer keeps the most recent log lines for the web console. It's safe
+// for concurrent use.
+type LogBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  uint64 // sequence number of the next line added
+	out   func(string)
+}
+
+// NewLogBuffer returns an empty buffer that also passes every line to out,
+// if set.
+func NewLogBuffer(out func(string)) *LogBuffer {
+	return &LogBuffer{out: out}
+}
+
+// Add appends a line, dropping the oldest once the buffer is full.
+func (b *LogBuffer) Add(line string) {
+	b.mu.Lock()
+	b.lines = append(b.lines, line)
+	if len(b.lines) > logLines {
+		b.lines = append(b.lines[:0], b.lines[len(b.lines)-logLines:]...)
+	}
+	b.next++
+	b.mu.Unlock()
+	if b.out != nil {
+		b.out(line)
+	}
+}
+
+// Since returns the lines added after sequence number seq that are still
+// buffered, and the sequence number to ask from next time.
+func (b *LogBuffer) Since(seq uint64) ([]string, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	first := b.next - uint64(len(b.lines))
+	if seq < first {
+		seq = first
+	}
+	if seq >= b.next {
+		return nil, b.next
+	}
+	return append([]string(nil), b.lines[seq-first:]...), b.next
+}
+
+// GET /api/log?since=N
+func (s *Server) handleLog(w http.ResponseWriter, r *http.Request) {
+	since, _ := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+	lines, next := s.logs.Since(since)
+	if lines == nil {
+		lines = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"lines": lines, "next": next})
+}
+
+// GET /api/events streams Server-Sent Events: "jobs" with every job, and
+// "status" with the queue summary, whenever they change, and "log" with new
+// log lines. A fresh connection starts with a full snapshot and the
+// buffered log.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	tick := time.NewTicker(eventInterval)
+	defer tick.Stop()
+
+	sentVersion := ^uint64(0)
+	var logSeq uint64
+	lastWrite := time.Now()
+	for {
+		wrote := false
+		if v := s.version.Load(); v != sentVersion {
+			sentVersion = v
+			jobs := s.queue.Jobs()
+			out := make([]jobJSON, 0, len(jobs))
+			for _, j := range jobs {
+				out = append(out, newJobJSON(j))
+			}
+			if !writeEvent(w, "jobs", out) || !writeEvent(w, "status", s.status()) {
+				return
+			}
+			wrote = true
+		}
+		var lines []string
+		lines, logSeq = s.logs.Since(logSeq)
+		if len(lines) > 0 {
+			if !writeEvent(w, "log", lines) {
+				return
+			}
+			wrote = true
+		}
+		if !wrote && time.Since(lastWrite) >= keepAliveInterval {
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			wrote = true
+		}
+		if wrote {
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// writeEvent writes one SSE event with v as JSON data and reports whether
+// the client is still there.
+func writeEvent(w http.ResponseWriter, name string, v any) bool {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+	return err == nil
+}
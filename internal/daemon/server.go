@@ -0,0 +1,252 @@
+// internal/daemon/server.go
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/jobstore"
+	"awesomeProject1/internal/scraper"
+	"awesomeProject1/internal/sysmap"
+)
+
+// shutdownTimeout bounds how long Serve waits for open requests on exit.
+const shutdownTimeout = 5 * time.Second
+
+// Config describes a daemon.
+type Config struct {
+	// Dir is the folder downloads are saved under.
+	Dir string
+
+	// Root, if set, sorts files into <Dir>/<system> folders, the system
+	// being guessed from the file's URL below Root, as the GUI does.
+	Root string
+
+	// Rules map file paths to folders below Dir, ahead of Profile and the
+	// guess from Root.
+	Rules []sysmap.Rule
+
+	// Profile, if set, is the built-in frontend layout to sort files by; it
+	// also decides which systems' archives are unpacked.
+	Profile string
+
+	// Token, if set, must accompany every API request, either as
+	// "Authorization: Bearer <token>" or as a token query parameter.
+	// Without one, only requests to localhost are answered.
+	Token string
+
+	// Jobs is how many files download at once.
+	Jobs int
+
+	// StatePath, if set, is a job store file. Unfinished jobs found there
+	// are resumed on start, and jobs still running at shutdown are resumed
+	// on the next start.
+	StatePath string
+
+	// Logs receives the download log and a line for every job that
+	// finishes; the web console shows the latest of them. nil keeps them
+	// only for the web console.
+	Logs *LogBuffer
+}
+
+// Server exposes a download queue over an HTTP JSON API, with a browser
+// frontend for it at "/" and an aria2-compatible JSON-RPC endpoint at
+// "/jsonrpc".
+type Server struct {
+	cfg   Config
+	mgr   *download.Manager
+	queue *download.Queue
+	idx   *scraper.HTTPIndex
+	store *jobstore.Store
+	mux   *http.ServeMux
+	logs  *LogBuffer
+
+	// folders applies cfg.Rules and cfg.Profile.
+	folders *sysmap.Mapper
+
+	// version counts job and setting changes, so event streams know when
+	// there's something new to send.
+	version atomic.Uint64
+
+	// done is closed on shutdown to end event streams.
+	done chan struct{}
+
+	// sessionID identifies this run to aria2 clients.
+	sessionID string
+
+	// closing stops jobs paused at shutdown being recorded as paused, so
+	// the next start picks them up again.
+	mu      sync.Mutex
+	closing bool
+}
+
+// New returns a daemon that downloads through mgr. Unfinished jobs from
+// cfg.StatePath are queued again straight away.
+func New(mgr *download.Manager, cfg Config) (*Server, error) {
+	if cfg.Logs == nil {
+		cfg.Logs = NewLogBuffer(nil)
+	}
+	folders, err := sysmap.New(cfg.Rules, cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+	mgr.SetExtractRule(folders.Unzip)
+	s := &Server{
+		cfg:   cfg,
+		mgr:   mgr,
+		queue: download.NewQueue(mgr, cfg.Jobs, 0),
+		idx:   scraper.NewHTTPIndex(),
+		mux:   http.NewServeMux(),
+		logs:  cfg.Logs,
+		done:  make(chan struct{}),
+
+		folders:   folders,
+		sessionID: newSessionID(),
+	}
+	s.idx.SetMirrors(mgr.Mirrors())
+
+	if cfg.StatePath != "" {
+		store, err := jobstore.Open(cfg.StatePath)
+		if err != nil {
+			return nil, err
+		}
+		s.store = store
+	}
+	s.queue.OnUpdate = s.onUpdate
+	s.routes()
+
+	if s.store != nil {
+		unfinished := s.store.Unfinished()
+		for _, r := range unfinished {
+			s.queue.Restore(r.Name, r.URL, r.TargetDir, r.Paused(), r.Attempts)
+		}
+		if len(unfinished) > 0 {
+			s.logs.Add(fmt.Sprintf("Restored %d unfinished downloads.", len(unfinished)))
+		}
+	}
+	return s, nil
+}
+
+// Queue returns the queue the API controls.
+func (s *Server) Queue() *download.Queue {
+	return s.queue
+}
+
+// onUpdate records job changes and logs finished jobs.
+func (s *Server) onUpdate(j *download.Job) {
+	s.version.Add(1)
+	s.mu.Lock()
+	closing := s.closing
+	s.mu.Unlock()
+	if closing {
+		return
+	}
+	if s.store != nil {
+		if err := s.store.Track(j); err != nil {
+			s.logs.Add("ERROR: saving job state: " + err.Error())
+		}
+	}
+	switch j.State() {
+	case download.JobDone:
+		s.logs.Add("done      " + j.Name)
+	case download.JobFailed:
+		s.logs.Add(fmt.Sprintf("FAILED    %s: %v", j.Name, j.Err()))
+	}
+}
+
+// ServeHTTP checks the token on API requests and dispatches. The web
+// frontend itself is public; it asks for the token when the API wants one.
+//
+// Without a token, only requests addressed to localhost are served, so a
+// page that rebinds its own name to 127.0.0.1 gets nowhere. Requests that
+// change anything must be JSON or carry an X-Requested-With header, which a
+// page on another origin can't send without asking first.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Token == "" && !localHost(r.Host) {
+		writeError(w, http.StatusMisdirectedRequest, fmt.Errorf("host %q is not served; use localhost", r.Host))
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="myrient"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead &&
+			!isJSON(r) && r.Header.Get("X-Requested-With") == "" {
+			writeError(w, http.StatusForbidden, errors.New("request must be application/json or carry X-Requested-With"))
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// localHost reports whether host, a Host header, names this machine's
+// loopback interface.
+func localHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.cfg.Token == "" {
+		return true
+	}
+	got := r.URL.Query().Get("token")
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		got = strings.TrimPrefix(h, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.cfg.Token)) == 1
+}
+
+// Serve listens on addr until ctx is done. Running downloads are then
+// stopped with their .part files kept, and with StatePath set they resume on
+// the next start.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	close(s.done)
+	sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(sctx)
+
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+	if s.store != nil {
+		if ferr := s.store.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	s.queue.PauseAll()
+	s.queue.Wait()
+	return err
+}
@@ -0,0 +1,260 @@
+// Browser frontend for the daemon's API. It mirrors the desktop app: load an
+// index, pick files, watch the queue and the log. Live updates come from
+// /api/events (Server-Sent Events).
+"use strict";
+
+const $ = (id) => document.getElementById(id);
+const tokenKey = "myrient-token";
+
+let entries = [];          // current listing
+const selected = new Set(); // selected entry URLs
+let events = null;
+
+// ---------- API ----------
+
+function token() {
+  return localStorage.getItem(tokenKey) || "";
+}
+
+async function api(method, path, body, retried) {
+  const opts = { method, headers: { "X-Requested-With": "myrient" } };
+  if (token()) opts.headers["Authorization"] = "Bearer " + token();
+  if (body !== undefined) {
+    opts.headers["Content-Type"] = "application/json";
+    opts.body = JSON.stringify(body);
+  }
+  const resp = await fetch(path, opts);
+  if (resp.status === 401 && !retried) {
+    const t = prompt("This downloader needs its access token:", token());
+    if (t !== null) {
+      localStorage.setItem(tokenKey, t);
+      connectEvents();
+      return api(method, path, body, true);
+    }
+  }
+  const data = await resp.json().catch(() => ({}));
+  if (!resp.ok) throw new Error(data.error || resp.statusText);
+  return data;
+}
+
+// ---------- formatting ----------
+
+function formatBytes(n) {
+  if (!n) return "0 B";
+  const units = ["B", "KB", "MB", "GB", "TB"];
+  let i = 0;
+  while (n >= 1024 && i < units.length - 1) { n /= 1024; i++; }
+  return n.toFixed(i ? 1 : 0) + " " + units[i];
+}
+
+function parseSize(s) {
+  s = s.trim().toLowerCase().replace(/i?b(\/s)?$/, "");
+  if (!s) return 0;
+  const m = s.match(/^([\d.]+)\s*([kmgt]?)$/);
+  if (!m) return null;
+  const mult = { "": 1, k: 1 << 10, m: 1 << 20, g: 1 << 30, t: 2 ** 40 }[m[2]];
+  return Math.round(parseFloat(m[1]) * mult);
+}
+
+function el(tag, props, ...children) {
+  const e = document.createElement(tag);
+  Object.assign(e, props || {});
+  for (const c of children) if (c != null) e.append(c);
+  return e;
+}
+
+// ---------- browser ----------
+
+async function load(url) {
+  $("url").value = url;
+  $("listStatus").textContent = "Loading…";
+  selected.clear();
+  try {
+    const q = new URLSearchParams({ url });
+    if ($("recursive").checked) q.set("recursive", "1");
+    entries = await api("GET", "/api/list?" + q);
+    $("listStatus").textContent = entries.length + " entries";
+    history.replaceState(null, "", "#" + encodeURIComponent(url));
+  } catch (err) {
+    entries = [];
+    $("listStatus").textContent = "Error: " + err.message;
+  }
+  renderEntries();
+}
+
+function visibleEntries() {
+  const f = $("filter").value.trim().toLowerCase();
+  return f ? entries.filter((e) => e.name.toLowerCase().includes(f)) : entries;
+}
+
+function renderEntries() {
+  const list = $("entries");
+  list.replaceChildren();
+  for (const e of visibleEntries()) {
+    const check = el("input", { type: "checkbox", checked: selected.has(e.url) });
+    check.addEventListener("change", () => {
+      check.checked ? selected.add(e.url) : selected.delete(e.url);
+      updateSelection();
+    });
+    const name = el("span", { className: "name", textContent: e.name });
+    if (e.dir) name.addEventListener("click", () => load(e.url));
+    const details = [];
+    if (e.size) details.push(formatBytes(e.size));
+    if (e.modified) details.push(e.modified.slice(0, 10));
+    list.append(el("li", { className: e.dir ? "dir" : "" },
+      check, name, el("span", { className: "details", textContent: details.join("  ") })));
+  }
+  updateSelection();
+}
+
+function updateSelection() {
+  let size = 0;
+  for (const e of entries) if (selected.has(e.url)) size += e.size || 0;
+  $("selCount").textContent = selected.size
+    ? selected.size + " selected" + (size ? " (" + formatBytes(size) + ")" : "")
+    : "";
+  $("downloadBtn").disabled = selected.size === 0;
+}
+
+async function downloadSelected() {
+  const urls = entries.filter((e) => selected.has(e.url)).map((e) => e.url);
+  try {
+    const jobs = await api("POST", "/api/jobs", { urls, recursive: $("recursive").checked });
+    appendLog(["Queued " + jobs.length + " files."]);
+    selected.clear();
+    renderEntries();
+  } catch (err) {
+    alert("Queueing failed: " + err.message);
+  }
+}
+
+// ---------- queue ----------
+
+function renderJobs(jobs) {
+  const list = $("jobs");
+  list.replaceChildren();
+  for (const j of jobs) {
+    const bar = el("progress", { max: j.bytes_total || 1, value: j.bytes_total ? j.bytes_done : 0 });
+    const buttons = [];
+    const action = (label, act) => {
+      const b = el("button", { type: "button", textContent: label });
+      b.addEventListener("click", () => api("POST", `/api/jobs/${j.id}/${act}`).catch((e) => alert(e.message)));
+      buttons.push(b);
+    };
+    if (j.state === "running" || j.state === "queued") action("Pause", "pause");
+    if (j.state === "paused") action("Resume", "resume");
+    if (j.state === "failed" || j.state === "cancelled") action("Retry", "retry");
+    if (j.state !== "done" && j.state !== "cancelled" && j.state !== "failed") action("Cancel", "cancel");
+
+    list.append(el("li", { className: "job " + j.state },
+      el("div", { className: "row" },
+        el("span", { className: "name", textContent: j.name }), ...buttons),
+      bar,
+      el("span", { className: "details", textContent: jobDetails(j) })));
+  }
+}
+
+function jobDetails(j) {
+  switch (j.state) {
+    case "running": {
+      if (j.next_retry) return `attempt ${j.attempts} failed (${j.error}), retrying soon`;
+      let s = formatBytes(j.bytes_done);
+      if (j.bytes_total) s += " / " + formatBytes(j.bytes_total);
+      if (j.speed) s += " @ " + formatBytes(j.speed) + "/s";
+      if (j.eta && j.eta !== "--") s += ", " + j.eta + " left";
+      return s;
+    }
+    case "failed":
+      return "failed: " + j.error;
+    case "done":
+      return "done, " + formatBytes(j.bytes_done);
+    default:
+      return j.state;
+  }
+}
+
+function renderStatus(st) {
+  let s = `${st.running} running, ${st.queued} queued, ${st.paused} paused, ${st.done} done`;
+  if (st.failed) s += `, ${st.failed} failed`;
+  if (st.speed) s += ` — ${formatBytes(st.speed)}/s`;
+  $("queueStatus").textContent = s;
+
+  const set = st.settings;
+  const fill = (id, v) => { if (document.activeElement !== $(id)) $(id).value = v; };
+  fill("concurrency", set.concurrency);
+  fill("connections", set.connections);
+  fill("rateLimit", set.rate_limit ? formatBytes(set.rate_limit) : "");
+}
+
+async function applySettings() {
+  const limit = parseSize($("rateLimit").value);
+  if (limit === null) {
+    alert("Limit must look like 500K or 2M.");
+    return;
+  }
+  try {
+    await api("PATCH", "/api/settings", {
+      concurrency: parseInt($("concurrency").value, 10) || 1,
+      connections: parseInt($("connections").value, 10) || 1,
+      rate_limit: limit,
+    });
+  } catch (err) {
+    alert("Saving settings failed: " + err.message);
+  }
+}
+
+// ---------- log ----------
+
+const maxLogLines = 500;
+
+function appendLog(lines) {
+  const log = $("log");
+  const atBottom = log.scrollTop + log.clientHeight >= log.scrollHeight - 4;
+  const all = (log.textContent ? log.textContent.split("\n") : []).concat(lines);
+  log.textContent = all.slice(-maxLogLines).join("\n");
+  if (atBottom) log.scrollTop = log.scrollHeight;
+}
+
+// ---------- events ----------
+
+function connectEvents() {
+  if (events) events.close();
+  $("log").textContent = "";
+  const q = token() ? "?token=" + encodeURIComponent(token()) : "";
+  events = new EventSource("/api/events" + q);
+  events.addEventListener("jobs", (e) => renderJobs(JSON.parse(e.data)));
+  events.addEventListener("status", (e) => renderStatus(JSON.parse(e.data)));
+  events.addEventListener("log", (e) => appendLog(JSON.parse(e.data)));
+  events.onerror = () => {
+    // EventSource reconnects by itself; a wrong token never will, so ask.
+    if (events.readyState === EventSource.CLOSED) api("GET", "/api/status").catch(() => {});
+  };
+}
+
+// ---------- wiring ----------
+
+$("urlForm").addEventListener("submit", (e) => {
+  e.preventDefault();
+  load($("url").value.trim());
+});
+$("upBtn").addEventListener("click", () => {
+  const u = $("url").value.trim().replace(/[^/]*\/?$/, "");
+  if (u) load(u);
+});
+$("filter").addEventListener("input", renderEntries);
+$("selectAll").addEventListener("click", () => {
+  for (const e of visibleEntries()) selected.add(e.url);
+  renderEntries();
+});
+$("selectNone").addEventListener("click", () => {
+  selected.clear();
+  renderEntries();
+});
+$("downloadBtn").addEventListener("click", downloadSelected);
+$("applySettings").addEventListener("click", applySettings);
+for (const b of document.querySelectorAll("[data-queue]")) {
+  b.addEventListener("click", () => api("POST", "/api/queue/" + b.dataset.queue).catch((e) => alert(e.message)));
+}
+
+connectEvents();
+if (location.hash.length > 1) load(decodeURIComponent(location.hash.slice(1)));
@@ -0,0 +1,231 @@
+// internal/dat/clrmamepro.go
+package dat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseClrMamePro reads the ClrMamePro text format:
+//
+//	clrmamepro ( name "System" version 20240101 )
+//	game ( name "Title" description "Title" rom ( name "Title.bin" size 1024 crc 0a1b2c3d ) )
+//
+// Unknown blocks and keys are skipped.
+func ParseClrMamePro(r io.Reader) (*Datafile, error) {
+	t := &cmpTokenizer{r: bufio.NewReader(r)}
+	d := &Datafile{}
+
+	for {
+		tok, err := t.next()
+		if err == io.EOF {
+			return d, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(tok) {
+		case "clrmamepro":
+			fields, err := t.block()
+			if err != nil {
+				return nil, err
+			}
+			d.Header = Header{
+				Name:        fields.get("name"),
+				Description: fields.get("description"),
+				Version:     fields.get("version"),
+				Author:      fields.get("author"),
+				Homepage:    fields.get("homepage"),
+			}
+		case "game", "machine", "resource":
+			fields, err := t.block()
+			if err != nil {
+				return nil, err
+			}
+			g := Game{
+				Name:        fields.get("name"),
+				Description: fields.get("description"),
+				CloneOf:     fields.get("cloneof"),
+				RomOf:       fields.get("romof"),
+			}
+			for _, rf := range fields.blocks["rom"] {
+				size, _ := strconv.ParseInt(rf.get("size"), 10, 64)
+				g.ROMs = append(g.ROMs, ROM{
+					Name:   rf.get("name"),
+					Size:   size,
+					CRC:    normalizeHex(rf.get("crc")),
+					MD5:    normalizeHex(rf.get("md5")),
+					SHA1:   normalizeHex(rf.get("sha1")),
+					Status: firstNonEmpty(rf.get("status"), rf.get("flags")),
+				})
+			}
+			d.Games = append(d.Games, g)
+		default:
+			// Unknown top-level block: skip it whole.
+			if _, err := t.block(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// cmpFields is one parenthesised block: key/value pairs plus nested blocks.
+type cmpFields struct {
+	values map[string]string
+	blocks map[string][]cmpFields
+}
+
+func (f cmpFields) get(key string) string {
+	return f.values[key]
+}
+
+type cmpTokenizer struct {
+	r    *bufio.Reader
+	line int
+}
+
+// block reads "( key value ... )" after its introducing keyword.
+func (t *cmpTokenizer) block() (cmpFields, error) {
+	f := cmpFields{values: map[string]string{}, blocks: map[string][]cmpFields{}}
+
+	open, err := t.next()
+	if err != nil {
+		return f, t.errorf("expected '(': %v", err)
+	}
+	if open != "(" {
+		return f, t.errorf("expected '(', got %q", open)
+	}
+
+	for {
+		key, err := t.next()
+		if err != nil {
+			return f, t.errorf("unterminated block: %v", err)
+		}
+		if key == ")" {
+			return f, nil
+		}
+		key = strings.ToLower(key)
+
+		val, err := t.peekIsOpen()
+		if err != nil {
+			return f, t.errorf("missing value for %q: %v", key, err)
+		}
+		if val {
+			sub, err := t.block()
+			if err != nil {
+				return f, err
+			}
+			f.blocks[key] = append(f.blocks[key], sub)
+			continue
+		}
+
+		v, err := t.next()
+		if err != nil {
+			return f, t.errorf("missing value for %q: %v", key, err)
+		}
+		if v == ")" {
+			// Flag-style key without a value closing the block.
+			return f, nil
+		}
+		f.values[key] = v
+	}
+}
+
+func (t *cmpTokenizer) skipSpace() error {
+	for {
+		b, err := t.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == '\n' {
+			t.line++
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return t.r.UnreadByte()
+		}
+	}
+}
+
+func (t *cmpTokenizer) peekIsOpen() (bool, error) {
+	if err := t.skipSpace(); err != nil {
+		return false, err
+	}
+	b, err := t.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	return b[0] == '(', nil
+}
+
+// next returns the next token: "(", ")", a quoted string without its quotes
+// (and with \" read as a quote) or a bare word.
+func (t *cmpTokenizer) next() (string, error) {
+	if err := t.skipSpace(); err != nil {
+		return "", err
+	}
+	b, err := t.r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch b {
+	case '(', ')':
+		return string(b), nil
+	case '"':
+		var sb strings.Builder
+		for {
+			c, err := t.r.ReadByte()
+			if err != nil {
+				return "", t.errorf("unterminated string")
+			}
+			if c == '"' {
+				return sb.String(), nil
+			}
+			if c == '\\' {
+				// Names and paths use backslashes as they are; only an
+				// escaped quote is unescaped.
+				if n, err := t.r.Peek(1); err == nil && n[0] == '"' {
+					c, _ = t.r.ReadByte()
+				}
+			}
+			if c == '\n' {
+				t.line++
+			}
+			sb.WriteByte(c)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteByte(b)
+	for {
+		c, err := t.r.ReadByte()
+		if err == io.EOF {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '(' || c == ')' {
+			t.r.UnreadByte()
+			return sb.String(), nil
+		}
+		sb.WriteByte(c)
+	}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (t *cmpTokenizer) errorf(format string, args ...any) error {
+	return fmt.Errorf("parse clrmamepro dat (line %d): %s", t.line+1, fmt.Sprintf(format, args...))
+}
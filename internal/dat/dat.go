@@ -0,0 +1,94 @@
+// internal/dat/dat.go
+package dat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Datafile is a parsed No-Intro/Redump style DAT.
+type Datafile struct {
+	Header Header
+	Games  []Game
+}
+
+// Header carries the descriptive fields of a DAT.
+type Header struct {
+	Name        string
+	Description string
+	Version     string
+	Author      string
+	Homepage    string
+}
+
+// Game is one set in a DAT. Clones name their parent in CloneOf.
+type Game struct {
+	Name        string
+	Description string
+	CloneOf     string
+	RomOf       string
+	ROMs        []ROM
+}
+
+// IsClone reports whether the game is a clone of another set.
+func (g Game) IsClone() bool {
+	return g.CloneOf != ""
+}
+
+// ROM is one file of a game. Digests are lowercase hex; Status is e.g.
+// "baddump", "nodump" or "verified" and empty when the DAT doesn't say.
+type ROM struct {
+	Name   string
+	Size   int64
+	CRC    string
+	MD5    string
+	SHA1   string
+	Status string
+}
+
+// Load reads a DAT file, detecting Logiqx XML or ClrMamePro text format.
+func Load(path string) (*Datafile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Parse reads a DAT from r, detecting the format from its first
+// non-whitespace character: '<' means Logiqx XML, anything else ClrMamePro.
+func Parse(r io.Reader) (*Datafile, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("empty dat: %w", err)
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+			continue
+		case 0xEF:
+			// UTF-8 byte order mark
+			br.Discard(3)
+			continue
+		case '<':
+			return ParseLogiqx(br)
+		default:
+			return ParseClrMamePro(br)
+		}
+	}
+}
+
+func normalizeHex(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
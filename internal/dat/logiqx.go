@@ -0,0 +1,121 @@
+// internal/dat/logiqx.go
+package dat
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type xmlDatafile struct {
+	XMLName  xml.Name  `xml:"datafile"`
+	Header   xmlHeader `xml:"header"`
+	Games    []xmlGame `xml:"game"`
+	Machines []xmlGame `xml:"machine,omitempty"`
+}
+
+type xmlHeader struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Version     string `xml:"version,omitempty"`
+	Author      string `xml:"author,omitempty"`
+	Homepage    string `xml:"homepage,omitempty"`
+}
+
+type xmlGame struct {
+	Name        string   `xml:"name,attr"`
+	CloneOf     string   `xml:"cloneof,attr,omitempty"`
+	RomOf       string   `xml:"romof,attr,omitempty"`
+	Description string   `xml:"description"`
+	ROMs        []xmlROM `xml:"rom"`
+}
+
+type xmlROM struct {
+	Name   string `xml:"name,attr"`
+	Size   int64  `xml:"size,attr,omitempty"`
+	CRC    string `xml:"crc,attr,omitempty"`
+	MD5    string `xml:"md5,attr,omitempty"`
+	SHA1   string `xml:"sha1,attr,omitempty"`
+	Status string `xml:"status,attr,omitempty"`
+}
+
+// ParseLogiqx reads a Logiqx XML datafile (the format used by No-Intro and
+// Redump). MAME-style <machine> elements are accepted as games.
+func ParseLogiqx(r io.Reader) (*Datafile, error) {
+	var x xmlDatafile
+	dec := xml.NewDecoder(r)
+	// DAT files routinely reference a DTD we have no use for.
+	dec.Strict = false
+	if err := dec.Decode(&x); err != nil {
+		return nil, fmt.Errorf("parse logiqx xml: %w", err)
+	}
+
+	d := &Datafile{
+		Header: Header{
+			Name:        x.Header.Name,
+			Description: x.Header.Description,
+			Version:     x.Header.Version,
+			Author:      x.Header.Author,
+			Homepage:    x.Header.Homepage,
+		},
+	}
+	for _, g := range append(x.Games, x.Machines...) {
+		game := Game{
+			Name:        g.Name,
+			Description: g.Description,
+			CloneOf:     g.CloneOf,
+			RomOf:       g.RomOf,
+		}
+		for _, r := range g.ROMs {
+			game.ROMs = append(game.ROMs, ROM{
+				Name:   r.Name,
+				Size:   r.Size,
+				CRC:    normalizeHex(r.CRC),
+				MD5:    normalizeHex(r.MD5),
+				SHA1:   normalizeHex(r.SHA1),
+				Status: r.Status,
+			})
+		}
+		d.Games = append(d.Games, game)
+	}
+	return d, nil
+}
+
+// WriteLogiqx writes d as a Logiqx XML datafile, e.g. a fixdat of missing
+// games that other ROM managers can load.
+func WriteLogiqx(w io.Writer, d *Datafile) error {
+	x := xmlDatafile{
+		Header: xmlHeader{
+			Name:        d.Header.Name,
+			Description: d.Header.Description,
+			Version:     d.Header.Version,
+			Author:      d.Header.Author,
+			Homepage:    d.Header.Homepage,
+		},
+	}
+	for _, g := range d.Games {
+		xg := xmlGame{
+			Name:        g.Name,
+			CloneOf:     g.CloneOf,
+			RomOf:       g.RomOf,
+			Description: g.Description,
+		}
+		for _, r := range g.ROMs {
+			xg.ROMs = append(xg.ROMs, xmlROM(r))
+		}
+		x.Games = append(x.Games, xg)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+logiqxDoctype+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(x); err != nil {
+		return fmt.Errorf("write logiqx xml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+const logiqxDoctype = `<!DOCTYPE datafile PUBLIC "-//Logiqx//DTD ROM Management Datafile//EN" "http://www.logiqx.com/Dats/datafile.dtd">`
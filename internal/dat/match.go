@@ -0,0 +1,69 @@
+// internal/dat/match.go
+package dat
+
+import (
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/util"
+)
+
+// Index looks up games by the file names used in HTTP listings. Myrient
+// serves one archive per game named after it ("Title (USA).zip"); loose
+// files are matched by ROM name instead.
+type Index struct {
+	dat    *Datafile
+	byGame map[string]*Game
+	byROM  map[string]*Game
+}
+
+// NewIndex builds an Index over d.
+func NewIndex(d *Datafile) *Index {
+	x := &Index{
+		dat:    d,
+		byGame: make(map[string]*Game, len(d.Games)),
+		byROM:  make(map[string]*Game, len(d.Games)),
+	}
+	for i := range d.Games {
+		g := &d.Games[i]
+		x.byGame[g.Name] = g
+		for _, r := range g.ROMs {
+			x.byROM[r.Name] = g
+		}
+	}
+	return x
+}
+
+// Datafile returns the DAT the index was built from.
+func (x *Index) Datafile() *Datafile {
+	return x.dat
+}
+
+// GameFor returns the game a listing file name belongs to.
+func (x *Index) GameFor(filename string) (*Game, bool) {
+	if util.IsArchiveName(filename) {
+		g, ok := x.byGame[strings.TrimSuffix(filename, filepath.Ext(filename))]
+		return g, ok
+	}
+	if g, ok := x.byROM[filename]; ok {
+		return g, true
+	}
+	g, ok := x.byGame[filename]
+	return g, ok
+}
+
+// Match returns the entries that belong to a game in the DAT, in listing
+// order. Directories never match.
+func (x *Index) Match(entries []domain.FileEntry) []domain.FileEntry {
+	var out []domain.FileEntry
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		if _, ok := x.GameFor(e.Name); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
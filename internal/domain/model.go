@@ -1,8 +1,15 @@
 package domain
 
+import "time"
+
 // FileEntry represents one item in an HTTP directory listing.
 type FileEntry struct {
 	Name  string // display name
 	URL   string // absolute URL to this entry
 	IsDir bool   // true if this entry is a directory
+
+	// Size and Modified come from the listing's Size and Date columns. Sizes
+	// are often rounded there ("1.2 GiB"). Both are zero when not listed.
+	Size     int64
+	Modified time.Time
 }
@@ -0,0 +1,287 @@
+// internal/download/checksum.go
+package download
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrChecksumMismatch is wrapped by every verification failure.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Checksums holds the size and digests of a file as lowercase hex. Empty
+// fields (and a Size of 0) are not checked.
+type Checksums struct {
+	Size  int64
+	CRC32 string
+	MD5   string
+	SHA1  string
+
+	// Members lists expected files inside a zip by name. Each is unpacked
+	// and hashed to check it.
+	Members map[string]Checksums
+}
+
+// Empty reports whether there is nothing to check.
+func (c Checksums) Empty() bool {
+	return !c.hasDigests() && len(c.Members) == 0
+}
+
+// hasDigests reports whether the file itself (not its members) has
+// anything to check, i.e. whether it needs hashing.
+func (c Checksums) hasDigests() bool {
+	return c.Size > 0 || c.CRC32 != "" || c.MD5 != "" || c.SHA1 != ""
+}
+
+// Verify compares got against the expected values in c. Members are not
+// looked at; see verifyZipMembers.
+func (c Checksums) Verify(got Checksums) error {
+	if c.Size > 0 && c.Size != got.Size {
+		return fmt.Errorf("%w: size is %d, expected %d", ErrChecksumMismatch, got.Size, c.Size)
+	}
+	for _, f := range []struct{ name, want, got string }{
+		{"CRC32", c.CRC32, got.CRC32},
+		{"MD5", c.MD5, got.MD5},
+		{"SHA1", c.SHA1, got.SHA1},
+	} {
+		if f.want != "" && !strings.EqualFold(f.want, f.got) {
+			return fmt.Errorf("%w: %s is %s, expected %s", ErrChecksumMismatch, f.name, f.got, strings.ToLower(f.want))
+		}
+	}
+	return nil
+}
+
+// ChecksumSource looks up the expected checksums of a file by name.
+type ChecksumSource interface {
+	Expected(filename string) (Checksums, bool)
+}
+
+// ChecksumSources asks each source in turn and returns the first match.
+type ChecksumSources []ChecksumSource
+
+func (s ChecksumSources) Expected(filename string) (Checksums, bool) {
+	for _, src := range s {
+		if src == nil {
+			continue
+		}
+		if c, ok := src.Expected(filename); ok {
+			return c, true
+		}
+	}
+	return Checksums{}, false
+}
+
+// ChecksumIndex is a ChecksumSource backed by a map, typically filled from
+// .sfv/.md5/.sha1 sidecar files. It's safe for concurrent use.
+type ChecksumIndex struct {
+	mu   sync.RWMutex
+	sums map[string]Checksums
+}
+
+func NewChecksumIndex() *ChecksumIndex {
+	return &ChecksumIndex{sums: map[string]Checksums{}}
+}
+
+// Add merges c into the entry for filename; non-empty fields win.
+func (x *ChecksumIndex) Add(filename string, c Checksums) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	cur := x.sums[filename]
+	if c.Size > 0 {
+		cur.Size = c.Size
+	}
+	if c.CRC32 != "" {
+		cur.CRC32 = strings.ToLower(c.CRC32)
+	}
+	if c.MD5 != "" {
+		cur.MD5 = strings.ToLower(c.MD5)
+	}
+	if c.SHA1 != "" {
+		cur.SHA1 = strings.ToLower(c.SHA1)
+	}
+	x.sums[filename] = cur
+}
+
+func (x *ChecksumIndex) Expected(filename string) (Checksums, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	c, ok := x.sums[filename]
+	return c, ok
+}
+
+// Len returns the number of files with known checksums.
+func (x *ChecksumIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.sums)
+}
+
+// LoadChecksumFile reads an .sfv ("name CRC32") or md5sum/sha1sum style
+// ("digest  name") file into x. The digest type of the latter is told apart
+// by its length, so the file extension doesn't matter.
+func (x *ChecksumIndex) LoadChecksumFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	sfv := strings.EqualFold(filepath.Ext(path), ".sfv")
+	added := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var name, digest string
+		if sfv {
+			i := strings.LastIndexAny(line, " \t")
+			if i < 0 {
+				continue
+			}
+			name, digest = strings.TrimSpace(line[:i]), line[i+1:]
+		} else {
+			d, rest, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			// "digest *name" marks binary mode in md5sum output.
+			digest, name = d, strings.TrimPrefix(strings.TrimLeft(rest, " "), "*")
+		}
+		if _, err := hex.DecodeString(digest); err != nil || name == "" {
+			continue
+		}
+
+		var c Checksums
+		switch len(digest) {
+		case 8:
+			c.CRC32 = digest
+		case 32:
+			c.MD5 = digest
+		case 40:
+			c.SHA1 = digest
+		default:
+			continue
+		}
+		x.Add(filepath.Base(filepath.ToSlash(name)), c)
+		added++
+	}
+	if err := sc.Err(); err != nil {
+		return added, fmt.Errorf("read %s: %w", path, err)
+	}
+	return added, nil
+}
+
+// verifyZipMembers checks that the zip at path holds every expected member
+// with the right size and digests. Members are hashed as they decompress, so
+// a damaged payload fails even when the central directory looks right; an
+// archive that can't be read at all fails the same way.
+func verifyZipMembers(path string, members map[string]Checksums) error {
+	r, err := zip.OpenReader(path)
+	if errors.Is(err, zip.ErrFormat) || errors.Is(err, zip.ErrAlgorithm) {
+		return fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+	} else if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	byName := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		byName[f.Name] = f
+	}
+	for name, want := range members {
+		f, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("%w: %s missing from archive", ErrChecksumMismatch, name)
+		}
+		h := newMultiHasher()
+		if err := hashMember(h, f); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrChecksumMismatch, name, err)
+		}
+		if err := want.Verify(h.Sum()); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// hashMember feeds f's decompressed data to h. archive/zip checks the data
+// against the member's CRC32 at the end and fails with zip.ErrChecksum.
+func hashMember(h *multiHasher, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(h, rc)
+	return err
+}
+
+// multiHasher computes CRC32, MD5 and SHA1 in one pass as data streams by.
+type multiHasher struct {
+	crc  hash.Hash32
+	md5  hash.Hash
+	sha1 hash.Hash
+	n    int64
+}
+
+func newMultiHasher() *multiHasher {
+	return &multiHasher{
+		crc:  crc32.NewIEEE(),
+		md5:  md5.New(),
+		sha1: sha1.New(),
+	}
+}
+
+func (h *multiHasher) Write(b []byte) (int, error) {
+	h.crc.Write(b)
+	h.md5.Write(b)
+	h.sha1.Write(b)
+	h.n += int64(len(b))
+	return len(b), nil
+}
+
+func (h *multiHasher) Sum() Checksums {
+	return Checksums{
+		Size:  h.n,
+		CRC32: hex.EncodeToString(h.crc.Sum(nil)),
+		MD5:   hex.EncodeToString(h.md5.Sum(nil)),
+		SHA1:  hex.EncodeToString(h.sha1.Sum(nil)),
+	}
+}
+
+// hashFile feeds the first n bytes of path into h (all of it if n < 0).
+func hashFile(h *multiHasher, path string, n int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if n >= 0 {
+		r = io.LimitReader(f, n)
+	}
+	copied, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+	if n >= 0 && copied != n {
+		return fmt.Errorf("hash %s: short read (%d of %d bytes)", path, copied, n)
+	}
+	return nil
+}
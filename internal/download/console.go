@@ -29,6 +29,10 @@ func (c *Console) LogCancelled() {
 	c.log("Download cancelled!")
 }
 
+func (c *Console) LogPaused(filename string, bytes int64) {
+	c.log(fmt.Sprintf("Paused %s at %d bytes.", filename, bytes))
+}
+
 func (c *Console) LogError(msg string) {
 	c.log("ERROR: " + msg)
 }
@@ -0,0 +1,48 @@
+// internal/download/datsums.go
+package download
+
+import (
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/dat"
+)
+
+// DATSource makes a ChecksumSource of a DAT. For a .zip the game's ROMs are
+// expected as archive members; a loose file is checked against the ROM of
+// the same name.
+func DATSource(idx *dat.Index) ChecksumSource {
+	return datSource{idx}
+}
+
+type datSource struct {
+	idx *dat.Index
+}
+
+func (s datSource) Expected(filename string) (Checksums, bool) {
+	g, ok := s.idx.GameFor(filename)
+	if !ok {
+		return Checksums{}, false
+	}
+
+	if strings.EqualFold(filepath.Ext(filename), ".zip") {
+		members := map[string]Checksums{}
+		for _, r := range g.ROMs {
+			if r.Status == "nodump" {
+				continue
+			}
+			members[r.Name] = Checksums{Size: r.Size, CRC32: r.CRC}
+		}
+		if len(members) == 0 {
+			return Checksums{}, false
+		}
+		return Checksums{Members: members}, true
+	}
+
+	for _, r := range g.ROMs {
+		if r.Name == filename && r.Status != "nodump" {
+			return Checksums{Size: r.Size, CRC32: r.CRC, MD5: r.MD5, SHA1: r.SHA1}, true
+		}
+	}
+	return Checksums{}, false
+}
@@ -2,15 +2,22 @@
 package download
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"awesomeProject1/internal/mirror"
 	"awesomeProject1/internal/util"
 )
 
@@ -21,13 +28,43 @@ type Progress struct {
 	ETA         string
 	Done        bool
 	Err         error
+	Attempt     int   // 1-based try number when run through DownloadFileWithRetry
+	Speed       int64 // recent transfer rate in bytes per second
+
+	// NextRetry is when the next attempt starts, set while waiting after a
+	// failed one (Err says why); zero otherwise.
+	NextRetry time.Time
 }
 
 type Manager struct {
 	client  *http.Client
 	console *Console
+
+	// segments is the number of parallel range requests used per file;
+	// 0 or 1 means a single stream.
+	segments atomic.Int32
+
+	// limiter caps the combined rate of every transfer.
+	limiter *RateLimiter
+
+	sumsMu sync.RWMutex
+	sums   ChecksumSource
+
+	mirrors atomic.Pointer[mirror.Set]
+
+	retry atomic.Pointer[util.RetryPolicy]
+
+	// keepArchives turns off unpacking downloaded .zip files.
+	keepArchives atomic.Bool
+
+	// extractRule, if set, decides per file ahead of keepArchives.
+	extractRule atomic.Pointer[ExtractRule]
 }
 
+// ExtractRule decides whether the archive downloaded from urlStr is
+// unpacked; ok false leaves it to SetExtract.
+type ExtractRule func(urlStr string) (extract, ok bool)
+
 func NewManager(console *Console) *Manager {
 	// Tuned transport so we can hammer a single host efficiently.
 	transport := &http.Transport{
@@ -45,38 +82,207 @@ func NewManager(console *Console) *Manager {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
-	client := &http.Client{
-		Transport: transport,
+	m := &Manager{
+		console: console,
+		limiter: NewRateLimiter(0),
+	}
+	m.SetRetryPolicy(util.DefaultRetryPolicy())
+	m.client = &http.Client{
+		Transport: mirror.Transport{Next: transport, Mirrors: m.Mirrors},
 		// no global Timeout: ROM sets can be huge; you can add per-request ctx later.
 	}
+	return m
+}
 
-	return &Manager{
-		client:  client,
-		console: console,
+// SetRateLimit caps the combined download rate of all transfers in bytes per
+// second; 0 removes the limit. Running transfers adapt immediately.
+func (m *Manager) SetRateLimit(bytesPerSec int64) {
+	m.limiter.SetLimit(bytesPerSec)
+}
+
+// RateLimit returns the global limit in bytes per second, 0 if unlimited.
+func (m *Manager) RateLimit() int64 {
+	return m.limiter.Limit()
+}
+
+// SetRetryPolicy sets how failed downloads are retried. It applies from the
+// next failure on, including to running jobs.
+func (m *Manager) SetRetryPolicy(p util.RetryPolicy) {
+	m.retry.Store(&p)
+}
+
+// RetryPolicy returns the current retry policy.
+func (m *Manager) RetryPolicy() util.RetryPolicy {
+	return *m.retry.Load()
+}
+
+// SetExtract sets whether downloaded .zip archives are unpacked next to
+// themselves and deleted (the default) or kept as they are.
+func (m *Manager) SetExtract(on bool) {
+	m.keepArchives.Store(!on)
+}
+
+// Extract reports whether downloaded archives are unpacked.
+func (m *Manager) Extract() bool {
+	return !m.keepArchives.Load()
+}
+
+// SetExtractRule lets rule decide, per file, whether archives are unpacked;
+// files it has no opinion on follow SetExtract. nil removes the rule.
+func (m *Manager) SetExtractRule(rule ExtractRule) {
+	if rule == nil {
+		m.extractRule.Store(nil)
+		return
+	}
+	m.extractRule.Store(&rule)
+}
+
+// extracts reports whether the archive downloaded from urlStr is unpacked.
+func (m *Manager) extracts(urlStr string) bool {
+	if rule := m.extractRule.Load(); rule != nil {
+		if extract, ok := (*rule)(urlStr); ok {
+			return extract
+		}
+	}
+	return m.Extract()
+}
+
+// SetChecksumSource sets where expected checksums come from. Files it knows
+// are hashed while downloading and fail with ErrChecksumMismatch if they
+// don't match; nil turns verification off.
+func (m *Manager) SetChecksumSource(src ChecksumSource) {
+	m.sumsMu.Lock()
+	defer m.sumsMu.Unlock()
+	m.sums = src
+}
+
+func (m *Manager) expected(filename string) (Checksums, bool) {
+	m.sumsMu.RLock()
+	src := m.sums
+	m.sumsMu.RUnlock()
+	if src == nil {
+		return Checksums{}, false
+	}
+	c, ok := src.Expected(filename)
+	if !ok || c.Empty() {
+		return Checksums{}, false
+	}
+	return c, true
+}
+
+// throttle waits until n more bytes fit under both the global limit and the
+// per-job limit carried by ctx, if any.
+func (m *Manager) throttle(ctx context.Context, n int) error {
+	if err := rateLimiterFrom(ctx).WaitN(ctx, n); err != nil {
+		return err
+	}
+	return m.limiter.WaitN(ctx, n)
+}
+
+// SetSegments sets how many connections a single file is split across.
+// Values below 2 disable segmented downloads. It takes effect for the next
+// file started.
+func (m *Manager) SetSegments(n int) {
+	if n < 1 {
+		n = 1
 	}
+	m.segments.Store(int32(n))
 }
 
-// DownloadFileWithRetry wraps DownloadFile with simple retry logic.
+// Segments returns the current per-file connection count.
+func (m *Manager) Segments() int {
+	return int(m.segments.Load())
+}
+
+// DownloadFileWithRetry wraps DownloadFile with the Manager's retry policy.
+// attempts, if above 0, overrides the policy's MaxAttempts.
 func (m *Manager) DownloadFileWithRetry(urlStr, targetDir string, cb func(Progress), attempts int) error {
-	if attempts < 1 {
-		attempts = 1
+	return m.DownloadFileWithRetryCtx(context.Background(), urlStr, targetDir, cb, attempts)
+}
+
+// DownloadFileWithRetryCtx is DownloadFileWithRetry with cancellation. It stops
+// retrying as soon as ctx is done. Between attempts cb gets the last Progress
+// with Err and NextRetry set. A failure down to the mirror moves on to the
+// next untried one at once, without using up an attempt.
+func (m *Manager) DownloadFileWithRetryCtx(ctx context.Context, urlStr, targetDir string, cb func(Progress), attempts int) error {
+	policy := m.RetryPolicy()
+	if attempts > 0 {
+		policy.MaxAttempts = attempts
 	}
-	var lastErr error
-	for i := 1; i <= attempts; i++ {
-		if m.console != nil && i > 1 {
-			m.console.Log(fmt.Sprintf("Retry %d/%d for %s", i, attempts, urlStr))
+	if cb == nil {
+		cb = func(Progress) {}
+	}
+
+	var last Progress
+	tried := map[string]bool{}
+	return policy.Do(ctx, func(attempt int) error {
+		if _, ok := m.nextSource(urlStr, tried); !ok {
+			// Every mirror has had its go; this attempt goes round again.
+			clear(tried)
 		}
-		lastErr = m.DownloadFile(urlStr, targetDir, cb)
-		if lastErr == nil {
-			return nil
+		src := m.pickSource(urlStr, tried)
+		if m.console != nil && attempt > 1 {
+			m.console.Log(fmt.Sprintf("Retry %d/%d for %s", attempt, policy.MaxAttempts, src))
 		}
-	}
-	return lastErr
+		for {
+			tried[src] = true
+			err := m.downloadFile(ctx, urlStr, src, targetDir, func(p Progress) {
+				p.Attempt = attempt
+				last = p
+				cb(p)
+			})
+			if err == nil || ctx.Err() != nil || !mirrorFault(err) {
+				return err
+			}
+			if errors.Is(err, ErrStalled) {
+				// The transport only sees the response headers.
+				m.Mirrors().Report(src, 0, err)
+			}
+			next, ok := m.nextSource(urlStr, tried)
+			if !ok {
+				return err
+			}
+			if m.console != nil {
+				m.console.Log(fmt.Sprintf("%s failed on %s (%v); trying %s.", fileNameFromURL(urlStr), src, err, next))
+			}
+			src = next
+		}
+	}, func(attempt int, next time.Time, err error) {
+		last.Attempt = attempt
+		last.Err = err
+		last.NextRetry = next
+		cb(last)
+		if m.console != nil {
+			m.console.Log(fmt.Sprintf(
+				"Waiting %s before retrying %s.",
+				util.FormatDuration(time.Until(next)), fileNameFromURL(urlStr),
+			))
+		}
+	})
 }
 
 // DownloadFile downloads a single URL into targetDir and reports progress via cb.
 // If the target .zip already exists, it will be skipped and still considered for extraction.
+//
+// Data is written to "<name>.part" and only renamed to the final name once the
+// expected number of bytes has arrived. A leftover .part from an earlier attempt
+// is resumed with a Range request, guarded by If-Range so a changed remote file
+// restarts from zero instead of producing a corrupt mix.
 func (m *Manager) DownloadFile(urlStr, targetDir string, cb func(Progress)) error {
+	return m.DownloadFileCtx(context.Background(), urlStr, targetDir, cb)
+}
+
+// DownloadFileCtx is DownloadFile with cancellation. When ctx is done the
+// request is aborted, the .part file is kept for a later resume and cb
+// receives a Progress whose Err is context.Cause(ctx).
+func (m *Manager) DownloadFileCtx(ctx context.Context, urlStr, targetDir string, cb func(Progress)) error {
+	return m.downloadFile(ctx, urlStr, m.pickSource(urlStr, nil), targetDir, cb)
+}
+
+// downloadFile downloads urlStr, fetching the bytes from src, which is
+// either urlStr itself or the same file on a mirror. The file is named and
+// its .part tracked after urlStr, so a resume can switch mirrors.
+func (m *Manager) downloadFile(ctx context.Context, urlStr, src, targetDir string, cb func(Progress)) error {
 	start := time.Now()
 	p := Progress{CurrentFile: urlStr}
 
@@ -85,20 +291,30 @@ func (m *Manager) DownloadFile(urlStr, targetDir string, cb func(Progress)) erro
 	}
 
 	if err := os.MkdirAll(targetDir, 0o755); err != nil {
-		p.Err = err
-		cb(p)
-		if m.console != nil {
-			m.console.LogError(err.Error())
-		}
-		return err
+		return m.fail(ctx, &p, cb, err)
 	}
 
-	// Determine filename from URL (simple approach; fine for Myrient)
-	filename := filepath.Base(urlStr)
-	if filename == "" || filename == "/" {
-		filename = "download.bin"
-	}
+	filename := fileNameFromURL(urlStr)
 	dstPath := filepath.Join(targetDir, filename)
+	partPath := dstPath + partSuffix
+	metaPath := dstPath + metaSuffix
+
+	want, verify := m.expected(filename)
+
+	// A file we have checksums for must prove itself before it's skipped.
+	if fi, err := os.Stat(dstPath); verify && err == nil && fi.Size() > 0 {
+		if err := verifyFile(dstPath, want, nil); err != nil {
+			if !errors.Is(err, ErrChecksumMismatch) {
+				return m.fail(ctx, &p, cb, err)
+			}
+			if m.console != nil {
+				m.console.Log(fmt.Sprintf("Existing %s failed verification (%v); downloading again.", filename, err))
+			}
+			if err := os.Remove(dstPath); err != nil {
+				return m.fail(ctx, &p, cb, err)
+			}
+		}
+	}
 
 	// If file already exists, skip download but still attempt unzip
 	if fi, err := os.Stat(dstPath); err == nil && fi.Size() > 0 {
@@ -111,94 +327,341 @@ func (m *Manager) DownloadFile(urlStr, targetDir string, cb func(Progress)) erro
 		cb(p)
 
 		// Try to unzip existing file if it's a .zip
-		return m.maybeUnzip(dstPath)
+		return m.maybeUnzip(urlStr, dstPath)
 	}
 
 	if m.console != nil {
-		m.console.Log(fmt.Sprintf("Downloading %s -> %s", urlStr, dstPath))
+		m.console.Log(fmt.Sprintf("Downloading %s -> %s", src, dstPath))
 	}
 
-	resp, err := m.client.Get(urlStr)
+	t := &transfer{
+		ctx:      ctx,
+		url:      urlStr,
+		src:      src,
+		filename: filename,
+		partPath: partPath,
+		metaPath: metaPath,
+		start:    start,
+		p:        p,
+		cb:       cb,
+		verify:   want.hasDigests(),
+	}
+
+	err := errRangesUnsupported
+	if n := m.Segments(); n > 1 {
+		err = m.downloadSegmented(t, n)
+	}
+	if err == errRangesUnsupported {
+		err = m.downloadStream(t)
+	}
 	if err != nil {
-		p.Err = err
-		cb(p)
+		return err
+	}
+	p = t.p
+
+	if verify {
+		// Both paths hash the data on the way in (t.hash); only if that
+		// couldn't be done is the file hashed here in one pass.
+		if err := verifyFile(partPath, want, t.hash); err != nil {
+			// Corrupt bytes can't be resumed from; the next attempt starts over.
+			removePart(partPath)
+			return m.fail(ctx, &p, cb, fmt.Errorf("%s: %w", filename, err))
+		}
 		if m.console != nil {
-			m.console.LogError(err.Error())
+			m.console.Log(fmt.Sprintf("Verified %s.", filename))
 		}
-		return err
+	}
+
+	if err := os.Rename(partPath, dstPath); err != nil {
+		return m.fail(ctx, &p, cb, err)
+	}
+	os.Remove(metaPath)
+
+	p.Done = true
+	cb(p)
+
+	if m.console != nil {
+		m.console.LogComplete()
+		m.console.Log(fmt.Sprintf(
+			"Downloaded %s (%s).",
+			filename,
+			util.FormatBytes(p.BytesDone, 2),
+		))
+	}
+
+	// After successful download, unzip if needed
+	return m.maybeUnzip(urlStr, dstPath)
+}
+
+// fileNameFromURL returns the local name for a download: the last path
+// segment, unescaped, so "Foo%20(USA).zip" is saved as "Foo (USA).zip".
+func fileNameFromURL(urlStr string) string {
+	name := path.Base(urlStr)
+	if u, err := url.Parse(urlStr); err == nil {
+		name = path.Base(u.Path)
+	}
+	if name == "" || name == "/" || name == "." || name == ".." {
+		return "download.bin"
+	}
+	return filepath.Base(name)
+}
+
+// transfer is the state shared by the single-stream and segmented paths while
+// filling a .part file.
+type transfer struct {
+	ctx      context.Context
+	url      string
+	filename string
+
+	// src is where the bytes come from: url itself or a mirror's copy.
+	src string
+
+	partPath string
+	metaPath string
+	start    time.Time
+	p        Progress
+	cb       func(Progress)
+
+	// verify asks the transfer to hash the data as it arrives into hash.
+	verify bool
+	hash   *multiHasher
+}
+
+// downloadStream fetches t.src with a single GET, resuming from whatever
+// contiguous prefix an earlier attempt left in the .part file.
+func (m *Manager) downloadStream(t *transfer) error {
+	ctx, p, cb := t.ctx, &t.p, t.cb
+
+	// Work out how much of an earlier attempt can be reused.
+	var offset int64
+	meta, metaErr := loadPartMeta(t.metaPath)
+	if fi, err := os.Stat(t.partPath); err == nil && fi.Size() > 0 {
+		if metaErr == nil && meta.URL == t.url && (meta.Total <= 0 || fi.Size() <= meta.Total) {
+			// A segmented attempt preallocates the whole file, so only the
+			// leading run of finished bytes can be trusted here.
+			offset = meta.contiguous(fi.Size())
+			if offset < fi.Size() {
+				if err := os.Truncate(t.partPath, offset); err != nil {
+					return m.fail(ctx, p, cb, err)
+				}
+			}
+			meta.Segments = nil
+		} else {
+			removePart(t.partPath)
+		}
+	}
+	if offset == 0 {
+		meta = partMeta{URL: t.url}
+	}
+	if offset > 0 && offset == meta.Total && meta.ifRangeValidator() != "" {
+		// An earlier attempt got every byte but stopped before the rename;
+		// asking for the rest would only get a 416.
+		return m.partComplete(t, offset)
+	}
+
+	reqCtx, guard, release := newStallGuard(ctx)
+	defer release()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, t.src, nil)
+	if err != nil {
+		return m.fail(ctx, p, cb, err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if v := meta.ifRangeValidator(); v != "" {
+			req.Header.Set("If-Range", v)
+		}
+	}
+
+	resp, err := m.client.Do(req)
+	guard.got()
+	if err != nil {
+		return m.fail(ctx, p, cb, stalled(reqCtx, err))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		p.Err = fmt.Errorf("http error: %s", resp.Status)
-		cb(p)
+	var total int64
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		rs, _, rt, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return m.fail(ctx, p, cb, err)
+		}
+		if rs != offset || (meta.Total > 0 && rt != meta.Total) || !meta.sameRemote(resp) {
+			// The server answered a different question than we asked; the
+			// bytes on disk can't be trusted, so start over on the next attempt.
+			removePart(t.partPath)
+			return m.fail(ctx, p, cb, fmt.Errorf("resume rejected for %s: unexpected Content-Range %q", t.filename, resp.Header.Get("Content-Range")))
+		}
+		total = rt
 		if m.console != nil {
-			m.console.LogError(p.Err.Error())
+			m.console.LogResuming(t.filename, offset)
 		}
-		return p.Err
+
+	case resp.StatusCode == http.StatusOK:
+		// Either a fresh download, or If-Range failed because the remote
+		// file changed and the server sent the whole thing.
+		if offset > 0 && m.console != nil {
+			m.console.Log(fmt.Sprintf("Remote file changed, restarting %s from zero.", t.filename))
+		}
+		offset = 0
+		total = resp.ContentLength
+
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		if rt, ok := unsatisfiedRange(resp.Header.Get("Content-Range")); ok && rt == offset && (meta.Total <= 0 || rt == meta.Total) {
+			// Nothing past the end: the .part already holds the whole file.
+			return m.partComplete(t, offset)
+		}
+		removePart(t.partPath)
+		return m.fail(ctx, p, cb, fmt.Errorf("resume rejected for %s: %s", t.filename, resp.Status))
+
+	default:
+		return m.fail(ctx, p, cb, util.NewHTTPStatusError(resp))
 	}
 
-	total := resp.ContentLength
-	p.BytesTotal = total
+	meta.ETag = resp.Header.Get("ETag")
+	meta.LastModified = resp.Header.Get("Last-Modified")
+	meta.Total = total
+	if err := savePartMeta(t.metaPath, meta); err != nil {
+		return m.fail(ctx, p, cb, err)
+	}
 
-	out, err := os.Create(dstPath)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	out, err := os.OpenFile(t.partPath, flags, 0o644)
 	if err != nil {
-		p.Err = err
-		cb(p)
-		if m.console != nil {
-			m.console.LogError(err.Error())
+		return m.fail(ctx, p, cb, err)
+	}
+
+	var sink io.Writer = out
+	if t.verify {
+		// Bytes from an earlier attempt are hashed once up front; the rest
+		// is hashed as it streams, so there's no second pass over the file.
+		t.hash = newMultiHasher()
+		if offset > 0 {
+			if err := hashFile(t.hash, t.partPath, offset); err != nil {
+				out.Close()
+				return m.fail(ctx, p, cb, err)
+			}
 		}
-		return err
+		sink = io.MultiWriter(out, t.hash)
 	}
-	defer out.Close()
 
+	p.BytesTotal = total
+	p.BytesDone = offset
+
+	var speed speedMeter
 	buf := make([]byte, 32*1024)
 	for {
+		guard.wait()
 		n, rerr := resp.Body.Read(buf)
+		guard.got()
 		if n > 0 {
-			if _, werr := out.Write(buf[:n]); werr != nil {
-				p.Err = werr
-				cb(p)
-				if m.console != nil {
-					m.console.LogError(werr.Error())
-				}
-				return werr
+			if _, werr := sink.Write(buf[:n]); werr != nil {
+				out.Close()
+				return m.fail(ctx, p, cb, werr)
 			}
 			p.BytesDone += int64(n)
-			p.ETA = util.CalculateETA(p.BytesDone, total, start)
-			cb(p)
+			p.Speed = int64(speed.add(int64(n)))
+			p.ETA = transferETA(p, offset, t.start)
+			cb(*p)
+			if werr := m.throttle(ctx, n); werr != nil {
+				out.Close()
+				return m.fail(ctx, p, cb, werr)
+			}
 		}
 		if rerr != nil {
 			if rerr == io.EOF {
 				break
 			}
-			p.Err = rerr
-			cb(p)
-			if m.console != nil {
-				m.console.LogError(rerr.Error())
-			}
-			return rerr
+			out.Close()
+			return m.fail(ctx, p, cb, stalled(reqCtx, rerr))
 		}
 	}
 
-	p.Done = true
-	cb(p)
+	if err := out.Close(); err != nil {
+		return m.fail(ctx, p, cb, err)
+	}
 
-	if m.console != nil {
-		m.console.LogComplete()
-		m.console.Log(fmt.Sprintf(
-			"Downloaded %s (%s).",
-			filename,
-			util.FormatBytes(p.BytesDone, 2),
+	// Keep the .part around for a later resume if the stream ended early.
+	if total > 0 && p.BytesDone != total {
+		return m.fail(ctx, p, cb, fmt.Errorf(
+			"incomplete download of %s: got %d of %d bytes", t.filename, p.BytesDone, total,
 		))
 	}
+	return nil
+}
 
-	// After successful download, unzip if needed
-	return m.maybeUnzip(dstPath)
+// partComplete finishes a transfer whose .part file already holds all size
+// bytes. It's verified, if need be, by hashing it whole.
+func (m *Manager) partComplete(t *transfer, size int64) error {
+	t.p.BytesTotal = size
+	t.p.BytesDone = size
+	t.cb(t.p)
+	if m.console != nil {
+		m.console.Log(fmt.Sprintf("%s was already complete.", t.filename))
+	}
+	return nil
+}
+
+// verifyFile checks the file at path against want. h carries the digests if
+// they were computed while streaming; otherwise the file is hashed here.
+// Archive members are hashed as they're unpacked from the zip.
+func verifyFile(path string, want Checksums, h *multiHasher) error {
+	if want.hasDigests() {
+		if h == nil {
+			h = newMultiHasher()
+			if err := hashFile(h, path, -1); err != nil {
+				return err
+			}
+		}
+		if err := want.Verify(h.Sum()); err != nil {
+			return err
+		}
+	}
+	if len(want.Members) > 0 {
+		return verifyZipMembers(path, want.Members)
+	}
+	return nil
+}
+
+// transferETA prefers the recent speed, which follows bandwidth limit
+// changes, and falls back to the session average until it's measured. Only
+// bytes fetched in this session count, otherwise a resumed file looks
+// impossibly fast.
+func transferETA(p *Progress, initial int64, start time.Time) string {
+	if p.Speed > 0 && p.BytesTotal > 0 {
+		return util.ETAFromRate(p.BytesTotal-p.BytesDone, float64(p.Speed))
+	}
+	return util.CalculateETA(p.BytesDone-initial, p.BytesTotal-initial, start)
+}
+
+// fail records err on p, reports it to cb and the console, and returns it.
+// Failures caused by ctx being cancelled are reported as the context's cause
+// (context.Canceled, or ErrPaused from a Queue) so callers can tell them
+// apart from network errors with errors.Is.
+func (m *Manager) fail(ctx context.Context, p *Progress, cb func(Progress), err error) error {
+	if ctx.Err() != nil {
+		err = context.Cause(ctx)
+	}
+	p.Err = err
+	cb(*p)
+	if m.console != nil {
+		switch {
+		case errors.Is(err, ErrPaused):
+			m.console.LogPaused(fileNameFromURL(p.CurrentFile), p.BytesDone)
+		case errors.Is(err, context.Canceled):
+			m.console.LogCancelled()
+		default:
+			m.console.LogError(err.Error())
+		}
+	}
+	return err
 }
 
-func (m *Manager) maybeUnzip(dstPath string) error {
-	if !strings.HasSuffix(strings.ToLower(dstPath), ".zip") {
+func (m *Manager) maybeUnzip(urlStr, dstPath string) error {
+	if !strings.HasSuffix(strings.ToLower(dstPath), ".zip") || !m.extracts(urlStr) {
 		return nil
 	}
 	if m.console != nil {
@@ -0,0 +1,62 @@
+// internal/download/mirrors.go
+package download
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"awesomeProject1/internal/mirror"
+	"awesomeProject1/internal/util"
+)
+
+// SetMirrors sets the mirrors downloads may be served from; nil disables
+// failover. Each new job starts on the healthiest mirror holding its URL.
+// When a mirror lacks the file, errors or stalls, the job moves straight on
+// to the next one it hasn't tried yet; once all have been tried, failures
+// are retried as the retry policy says.
+func (m *Manager) SetMirrors(s *mirror.Set) {
+	m.mirrors.Store(s)
+}
+
+// Mirrors returns the mirror set, or nil.
+func (m *Manager) Mirrors() *mirror.Set {
+	return m.mirrors.Load()
+}
+
+// pickSource returns the URL to fetch urlStr from: the best-ranked mirror
+// copy not in tried, or the best overall once all have been tried.
+func (m *Manager) pickSource(urlStr string, tried map[string]bool) string {
+	if src, ok := m.nextSource(urlStr, tried); ok {
+		return src
+	}
+	return m.Mirrors().Candidates(urlStr)[0]
+}
+
+// nextSource returns the best-ranked mirror copy of urlStr not in tried;
+// ok is false once all have been tried.
+func (m *Manager) nextSource(urlStr string, tried map[string]bool) (string, bool) {
+	for _, c := range m.Mirrors().Candidates(urlStr) {
+		if !tried[c] {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// mirrorFault reports whether err is down to the server rather than the
+// file, so another mirror may well serve it: the file is missing or refused
+// there, or the server fails, stalls or drops the connection.
+func mirrorFault(err error) bool {
+	var se *util.HTTPStatusError
+	if errors.As(err, &se) {
+		switch se.Code {
+		case http.StatusForbidden, http.StatusNotFound, http.StatusGone, http.StatusTooManyRequests:
+			return true
+		}
+		return se.Code >= 500
+	}
+	var ne net.Error
+	return errors.Is(err, ErrStalled) || errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,183 @@
+// internal/download/partial.go
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	partSuffix = ".part"
+	metaSuffix = ".part.json"
+)
+
+// partMeta is stored next to a .part file so a later attempt can tell whether
+// the bytes already on disk still belong to the same remote file.
+type partMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Total        int64     `json:"total"`
+	Segments     []segment `json:"segments,omitempty"`
+}
+
+// segment is one byte range [Start, End) of a segmented download, of which
+// the first Done bytes have been written.
+type segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+func (s segment) complete() bool {
+	return s.Start+s.Done >= s.End
+}
+
+// splitSegments divides [0, total) into n ranges, treating the first prefix
+// bytes as already downloaded.
+func splitSegments(total int64, n int, prefix int64) []segment {
+	if n < 1 {
+		n = 1
+	}
+	size := total / int64(n)
+	segs := make([]segment, 0, n)
+	for i := 0; i < n; i++ {
+		s := segment{Start: int64(i) * size, End: int64(i+1) * size}
+		if i == n-1 {
+			s.End = total
+		}
+		switch {
+		case prefix >= s.End:
+			s.Done = s.End - s.Start
+		case prefix > s.Start:
+			s.Done = prefix - s.Start
+		}
+		segs = append(segs, s)
+	}
+	return segs
+}
+
+// contiguous returns how many bytes from the start of the file are known to
+// be written, which is what a single-stream resume can safely continue from.
+func (meta partMeta) contiguous(fileSize int64) int64 {
+	if len(meta.Segments) == 0 {
+		return fileSize
+	}
+	var n int64
+	for _, s := range meta.Segments {
+		if s.Start != n {
+			break
+		}
+		n += s.Done
+		if !s.complete() {
+			break
+		}
+	}
+	return n
+}
+
+// segmentsDone sums the bytes already written across all segments.
+func (meta partMeta) segmentsDone() int64 {
+	var n int64
+	for _, s := range meta.Segments {
+		n += s.Done
+	}
+	return n
+}
+
+func loadPartMeta(path string) (partMeta, error) {
+	var meta partMeta
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return meta, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return meta, nil
+}
+
+func savePartMeta(path string, meta partMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// removePart deletes a .part file and its metadata, ignoring missing files.
+func removePart(partPath string) {
+	os.Remove(partPath)
+	os.Remove(strings.TrimSuffix(partPath, partSuffix) + metaSuffix)
+}
+
+// ifRangeValidator returns the value to send in If-Range. Weak ETags are not
+// allowed there, so Last-Modified is used instead when that's all we have.
+func (meta partMeta) ifRangeValidator() string {
+	if meta.ETag != "" && !strings.HasPrefix(meta.ETag, "W/") {
+		return meta.ETag
+	}
+	return meta.LastModified
+}
+
+// sameRemote reports whether resp still describes the file recorded in meta.
+func (meta partMeta) sameRemote(resp *http.Response) bool {
+	if etag := resp.Header.Get("ETag"); etag != "" && meta.ETag != "" && etag != meta.ETag {
+		return false
+	}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" && meta.LastModified != "" && lm != meta.LastModified {
+		return false
+	}
+	return true
+}
+
+// unsatisfiedRange reads the file size from the "bytes */total" header of a
+// 416 response.
+func unsatisfiedRange(v string) (int64, bool) {
+	size, ok := strings.CutPrefix(strings.TrimSpace(v), "bytes */")
+	if !ok {
+		return 0, false
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	return total, err == nil
+}
+
+// parseContentRange parses a "bytes start-end/total" header. total is -1
+// when the server sends "*".
+func parseContentRange(v string) (start, end, total int64, err error) {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "bytes ") {
+		return 0, 0, 0, fmt.Errorf("unsupported Content-Range %q", v)
+	}
+	v = strings.TrimPrefix(v, "bytes ")
+
+	rng, size, ok := strings.Cut(v, "/")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("malformed Content-Range %q", v)
+	}
+	first, last, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("malformed Content-Range %q", v)
+	}
+
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed Content-Range %q", v)
+	}
+	if end, err = strconv.ParseInt(last, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed Content-Range %q", v)
+	}
+	if size == "*" {
+		return start, end, -1, nil
+	}
+	if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed Content-Range %q", v)
+	}
+	if start > end || end >= total {
+		return 0, 0, 0, fmt.Errorf("Content-Range out of bounds %q", v)
+	}
+	return start, end, total, nil
+}
@@ -0,0 +1,485 @@
+// internal/download/queue.go
+package download
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// JobState is the lifecycle state of a queued download.
+type JobState int
+
+const (
+	JobQueued JobState = iota
+	JobRunning
+	JobDone
+	JobFailed
+	JobCancelled
+	JobPaused
+)
+
+// ErrPaused is the cancellation cause for a job paused through the Queue.
+// The job's .part file is kept and it resumes from there with a Range request.
+var ErrPaused = errors.New("download paused")
+
+func (s JobState) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	case JobCancelled:
+		return "cancelled"
+	case JobPaused:
+		return "paused"
+	}
+	return "unknown"
+}
+
+// Finished reports whether the job has reached a terminal state.
+func (s JobState) Finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// Job is one file in a Queue. The exported fields never change after Add;
+// everything else is read through methods because it's updated from the
+// download goroutine.
+type Job struct {
+	ID        int
+	Name      string
+	URL       string
+	TargetDir string
+
+	mu       sync.Mutex
+	state    JobState
+	progress Progress
+	err      error
+	attempts int
+	cancel   context.CancelCauseFunc
+
+	// limiter applies on top of the Manager's global limit.
+	limiter *RateLimiter
+}
+
+func (j *Job) State() JobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state
+}
+
+func (j *Job) Progress() Progress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress
+}
+
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// SetRateLimit caps this job's rate in bytes per second (0 = only the global
+// limit applies). It takes effect immediately, even mid-transfer.
+func (j *Job) SetRateLimit(bytesPerSec int64) {
+	j.limiter.SetLimit(bytesPerSec)
+}
+
+// RateLimit returns the job's own limit in bytes per second, 0 if none.
+func (j *Job) RateLimit() int64 {
+	return j.limiter.Limit()
+}
+
+// Attempts is the number of download tries so far, including those made in
+// an earlier session for a job brought back with Restore.
+func (j *Job) Attempts() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.attempts
+}
+
+// QueueStats counts the jobs in a Queue by state.
+type QueueStats struct {
+	Queued, Running, Paused, Done, Failed, Cancelled int
+}
+
+// Total is the number of jobs counted.
+func (s QueueStats) Total() int {
+	return s.Queued + s.Running + s.Paused + s.Done + s.Failed + s.Cancelled
+}
+
+// Busy reports whether any job is waiting for or holding a slot.
+func (s QueueStats) Busy() bool {
+	return s.Queued+s.Running > 0
+}
+
+// Finished is the number of jobs in a terminal state.
+func (s QueueStats) Finished() int {
+	return s.Done + s.Failed + s.Cancelled
+}
+
+// Queue runs downloads through a Manager with a bounded number of jobs in
+// flight. Jobs start in the order they were added.
+type Queue struct {
+	mgr      *Manager
+	attempts int
+
+	// OnUpdate, if set, is called after every state or progress change of a
+	// job. It runs on the download goroutine; set it before adding jobs.
+	OnUpdate func(*Job)
+
+	mu      sync.Mutex
+	jobs    []*Job
+	nextID  int
+	limit   int
+	running int
+	idle    *sync.Cond
+}
+
+// NewQueue creates a queue that runs at most concurrency jobs at once and
+// tries each file up to attempts times; 0 leaves that to the Manager's
+// retry policy.
+func NewQueue(mgr *Manager, concurrency, attempts int) *Queue {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	q := &Queue{
+		mgr:      mgr,
+		attempts: attempts,
+		limit:    concurrency,
+	}
+	q.idle = sync.NewCond(&q.mu)
+	return q
+}
+
+// SetConcurrency changes how many jobs may run at once. Lowering it doesn't
+// interrupt running jobs; new ones just wait until enough have finished.
+func (q *Queue) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	q.mu.Lock()
+	q.limit = n
+	started := q.scheduleLocked()
+	q.mu.Unlock()
+	q.notify(started)
+}
+
+// Concurrency returns how many jobs may run at once.
+func (q *Queue) Concurrency() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.limit
+}
+
+// Add appends a download to the queue and starts it once a slot is free.
+func (q *Queue) Add(name, urlStr, targetDir string) *Job {
+	return q.add(name, urlStr, targetDir, JobQueued, 0)
+}
+
+// Restore re-adds a job recorded in an earlier session, keeping its attempt
+// count. A paused job stays paused until Resume.
+func (q *Queue) Restore(name, urlStr, targetDir string, paused bool, attempts int) *Job {
+	state := JobQueued
+	if paused {
+		state = JobPaused
+	}
+	return q.add(name, urlStr, targetDir, state, attempts)
+}
+
+func (q *Queue) add(name, urlStr, targetDir string, state JobState, attempts int) *Job {
+	q.mu.Lock()
+	q.nextID++
+	j := &Job{
+		ID:        q.nextID,
+		Name:      name,
+		URL:       urlStr,
+		TargetDir: targetDir,
+		state:     state,
+		attempts:  attempts,
+		limiter:   NewRateLimiter(0),
+	}
+	q.jobs = append(q.jobs, j)
+	started := q.scheduleLocked()
+	q.mu.Unlock()
+
+	q.notify([]*Job{j})
+	q.notify(started)
+	return j
+}
+
+// Jobs returns a snapshot of all jobs in the order they were added.
+func (q *Queue) Jobs() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*Job(nil), q.jobs...)
+}
+
+// Job returns the job with the given ID, or nil if the queue doesn't hold it.
+func (q *Queue) Job(id int) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, j := range q.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// Stats counts the jobs currently held by the queue.
+func (q *Queue) Stats() QueueStats {
+	var s QueueStats
+	for _, j := range q.Jobs() {
+		switch j.State() {
+		case JobQueued:
+			s.Queued++
+		case JobRunning:
+			s.Running++
+		case JobPaused:
+			s.Paused++
+		case JobDone:
+			s.Done++
+		case JobFailed:
+			s.Failed++
+		case JobCancelled:
+			s.Cancelled++
+		}
+	}
+	return s
+}
+
+// ClearFinished drops jobs in a terminal state from the queue.
+func (q *Queue) ClearFinished() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.jobs[:0]
+	for _, j := range q.jobs {
+		if !j.State().Finished() {
+			kept = append(kept, j)
+		}
+	}
+	for i := len(kept); i < len(q.jobs); i++ {
+		q.jobs[i] = nil
+	}
+	q.jobs = kept
+}
+
+// Remove drops a finished job from the queue and reports whether it did.
+// Unfinished jobs have to be cancelled first.
+func (q *Queue) Remove(j *Job) bool {
+	if !j.State().Finished() {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, qj := range q.jobs {
+		if qj == j {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Cancel stops a job. A running transfer is aborted and its .part file kept;
+// a queued or paused one simply never starts.
+func (q *Queue) Cancel(j *Job) {
+	j.mu.Lock()
+	switch j.state {
+	case JobQueued, JobPaused:
+		j.state = JobCancelled
+		j.err = context.Canceled
+		j.progress.Err = context.Canceled
+		j.mu.Unlock()
+		q.notify([]*Job{j})
+		q.mu.Lock()
+		q.idle.Broadcast()
+		q.mu.Unlock()
+		return
+	case JobRunning:
+		j.cancel(context.Canceled)
+	}
+	j.mu.Unlock()
+}
+
+// CancelAll cancels every job that hasn't finished yet.
+func (q *Queue) CancelAll() {
+	for _, j := range q.Jobs() {
+		q.Cancel(j)
+	}
+}
+
+// Pause stops a job without giving up on it. A running transfer is aborted,
+// its slot goes to the next queued job and the bytes already written stay in
+// the .part file until Resume.
+func (q *Queue) Pause(j *Job) {
+	j.mu.Lock()
+	switch j.state {
+	case JobQueued:
+		j.state = JobPaused
+		j.mu.Unlock()
+		q.notify([]*Job{j})
+		q.mu.Lock()
+		q.idle.Broadcast()
+		q.mu.Unlock()
+		return
+	case JobRunning:
+		j.cancel(ErrPaused)
+	}
+	j.mu.Unlock()
+}
+
+// Resume puts a paused job back in line. It keeps its original position, so
+// it starts ahead of jobs added after it.
+func (q *Queue) Resume(j *Job) {
+	j.mu.Lock()
+	if j.state != JobPaused {
+		j.mu.Unlock()
+		return
+	}
+	j.state = JobQueued
+	j.progress.Err = nil
+	j.mu.Unlock()
+
+	q.mu.Lock()
+	started := q.scheduleLocked()
+	q.mu.Unlock()
+
+	q.notify([]*Job{j})
+	q.notify(started)
+}
+
+// Retry puts a failed or cancelled job back in line at its original
+// position. Its .part file, if any, is resumed from.
+func (q *Queue) Retry(j *Job) {
+	j.mu.Lock()
+	if j.state != JobFailed && j.state != JobCancelled {
+		j.mu.Unlock()
+		return
+	}
+	j.state = JobQueued
+	j.err = nil
+	j.progress.Err = nil
+	j.progress.Done = false
+	j.mu.Unlock()
+
+	q.mu.Lock()
+	started := q.scheduleLocked()
+	q.mu.Unlock()
+
+	q.notify([]*Job{j})
+	q.notify(started)
+}
+
+// PauseAll pauses every queued or running job.
+func (q *Queue) PauseAll() {
+	for _, j := range q.Jobs() {
+		q.Pause(j)
+	}
+}
+
+// ResumeAll resumes every paused job.
+func (q *Queue) ResumeAll() {
+	for _, j := range q.Jobs() {
+		q.Resume(j)
+	}
+}
+
+// Wait blocks until no job is queued or running. Paused jobs don't count,
+// otherwise a paused queue would block forever.
+func (q *Queue) Wait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.busyLocked() {
+		q.idle.Wait()
+	}
+}
+
+func (q *Queue) busyLocked() bool {
+	for _, j := range q.jobs {
+		if st := j.State(); st == JobQueued || st == JobRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleLocked starts queued jobs while slots are free and returns them.
+// q.mu must be held.
+func (q *Queue) scheduleLocked() []*Job {
+	var started []*Job
+	for _, j := range q.jobs {
+		if q.running >= q.limit {
+			break
+		}
+		j.mu.Lock()
+		if j.state != JobQueued {
+			j.mu.Unlock()
+			continue
+		}
+		ctx, cancel := context.WithCancelCause(WithRateLimiter(context.Background(), j.limiter))
+		j.state = JobRunning
+		j.err = nil
+		j.cancel = cancel
+		j.mu.Unlock()
+
+		q.running++
+		started = append(started, j)
+		go q.run(ctx, j)
+	}
+	return started
+}
+
+func (q *Queue) run(ctx context.Context, j *Job) {
+	lastAttempt := 0
+	err := q.mgr.DownloadFileWithRetryCtx(ctx, j.URL, j.TargetDir, func(p Progress) {
+		j.mu.Lock()
+		if p.Attempt > lastAttempt {
+			j.attempts += p.Attempt - lastAttempt
+			lastAttempt = p.Attempt
+		}
+		j.progress = p
+		j.mu.Unlock()
+		q.notify([]*Job{j})
+	}, q.attempts)
+
+	j.mu.Lock()
+	j.cancel(nil)
+	j.err = err
+	switch {
+	case err == nil:
+		j.state = JobDone
+	case errors.Is(err, ErrPaused):
+		j.state = JobPaused
+		j.err = nil
+	case errors.Is(err, context.Canceled):
+		j.state = JobCancelled
+	default:
+		j.state = JobFailed
+	}
+	j.mu.Unlock()
+
+	q.mu.Lock()
+	q.running--
+	started := q.scheduleLocked()
+	q.idle.Broadcast()
+	q.mu.Unlock()
+
+	q.notify([]*Job{j})
+	q.notify(started)
+}
+
+func (q *Queue) notify(jobs []*Job) {
+	if q.OnUpdate == nil {
+		return
+	}
+	for _, j := range jobs {
+		q.OnUpdate(j)
+	}
+}
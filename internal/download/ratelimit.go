@@ -0,0 +1,142 @@
+// internal/download/ratelimit.go
+package download
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// minBurst keeps the bucket large enough for one full read buffer, otherwise
+// a very low limit could never grant a 32 KiB chunk.
+const minBurst = 32 * 1024
+
+// RateLimiter is a token bucket measured in bytes per second. One limiter can
+// be shared by any number of transfers; a limit of 0 means unlimited. The
+// limit can be changed while transfers are running.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing bytesPerSec (0 = unlimited).
+func NewRateLimiter(bytesPerSec int64) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetLimit(bytesPerSec)
+	return l
+}
+
+// SetLimit changes the allowed rate. Waiting transfers pick it up within a
+// fraction of a second.
+func (l *RateLimiter) SetLimit(bytesPerSec int64) {
+	if bytesPerSec < 0 {
+		bytesPerSec = 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(bytesPerSec)
+	l.tokens = 0
+	l.last = time.Now()
+}
+
+// Limit returns the allowed rate in bytes per second, 0 if unlimited.
+func (l *RateLimiter) Limit() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(l.rate)
+}
+
+// WaitN blocks until n bytes may be transferred or ctx is done. A nil
+// limiter never blocks.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		if l.rate == 0 {
+			l.mu.Unlock()
+			return nil
+		}
+
+		now := time.Now()
+		burst := l.rate / 4
+		if burst < minBurst {
+			burst = minBurst
+		}
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > burst {
+			l.tokens = burst
+		}
+		l.last = now
+
+		if l.tokens >= float64(n) {
+			l.tokens -= float64(n)
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((float64(n) - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		// Re-check regularly so a raised or removed limit applies quickly.
+		if wait > 100*time.Millisecond {
+			wait = 100 * time.Millisecond
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+type jobLimiterKey struct{}
+
+// WithRateLimiter returns a context whose downloads are additionally limited
+// by l, on top of the Manager's global limit.
+func WithRateLimiter(ctx context.Context, l *RateLimiter) context.Context {
+	return context.WithValue(ctx, jobLimiterKey{}, l)
+}
+
+func rateLimiterFrom(ctx context.Context) *RateLimiter {
+	l, _ := ctx.Value(jobLimiterKey{}).(*RateLimiter)
+	return l
+}
+
+// speedMeter tracks a smoothed transfer rate so the ETA follows changes in
+// the bandwidth limit instead of the whole-transfer average.
+type speedMeter struct {
+	windowStart time.Time
+	windowBytes int64
+	rate        float64
+}
+
+const speedWindow = 500 * time.Millisecond
+
+// add records n transferred bytes and returns the current rate in bytes/s,
+// or 0 until the first window has been measured.
+func (s *speedMeter) add(n int64) float64 {
+	now := time.Now()
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+	s.windowBytes += n
+	if elapsed := now.Sub(s.windowStart); elapsed >= speedWindow {
+		inst := float64(s.windowBytes) / elapsed.Seconds()
+		if s.rate == 0 {
+			s.rate = inst
+		} else {
+			s.rate = 0.7*s.rate + 0.3*inst
+		}
+		s.windowStart = now
+		s.windowBytes = 0
+	}
+	return s.rate
+}
@@ -0,0 +1,350 @@
+// internal/download/segmented.go
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/util"
+)
+
+const (
+	// minSegmentedSize is the smallest file worth splitting; below this the
+	// extra requests cost more than they gain.
+	minSegmentedSize = 8 << 20
+
+	// segmentAttempts is how often a single segment is retried before the
+	// whole file is given up to the outer retry loop.
+	segmentAttempts = 3
+
+	// metaSaveInterval is how often segment progress is written to disk.
+	metaSaveInterval = time.Second
+)
+
+// errRangesUnsupported tells DownloadFileCtx to fall back to a single stream.
+var errRangesUnsupported = errors.New("server does not support byte ranges")
+
+// errRemoteChanged means the remote file no longer matches the .part file.
+var errRemoteChanged = errors.New("remote file changed during download")
+
+// downloadSegmented fills t.partPath using n parallel range requests written
+// at their offsets into a preallocated file. Segment progress is kept in the
+// .part.json sidecar so an interrupted transfer resumes each segment where it
+// stopped. It returns errRangesUnsupported, without touching disk, when the
+// server doesn't advertise "Accept-Ranges: bytes" or the file is too small.
+func (m *Manager) downloadSegmented(t *transfer, n int) error {
+	ctx, p, cb := t.ctx, &t.p, t.cb
+
+	headCtx, _, release := newStallGuard(ctx)
+	defer release()
+	req, err := http.NewRequestWithContext(headCtx, http.MethodHead, t.src, nil)
+	if err != nil {
+		return m.fail(ctx, p, cb, err)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		if err := stalled(headCtx, err); ctx.Err() != nil || err == ErrStalled {
+			return m.fail(ctx, p, cb, err)
+		}
+		return errRangesUnsupported
+	}
+	resp.Body.Close()
+
+	total := resp.ContentLength
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Accept-Ranges") != "bytes" || total < minSegmentedSize {
+		return errRangesUnsupported
+	}
+
+	meta, metaErr := loadPartMeta(t.metaPath)
+	fi, statErr := os.Stat(t.partPath)
+	reuse := metaErr == nil && statErr == nil &&
+		meta.URL == t.url && meta.Total == total && meta.sameRemote(resp)
+	switch {
+	case reuse && len(meta.Segments) == 0:
+		// Left behind by a single-stream attempt: its bytes are one
+		// contiguous prefix.
+		meta.Segments = splitSegments(total, n, fi.Size())
+	case !reuse:
+		removePart(t.partPath)
+		meta = partMeta{URL: t.url, Segments: splitSegments(total, n, 0)}
+	}
+	meta.ETag = resp.Header.Get("ETag")
+	meta.LastModified = resp.Header.Get("Last-Modified")
+	meta.Total = total
+
+	out, err := os.OpenFile(t.partPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return m.fail(ctx, p, cb, err)
+	}
+	if err := out.Truncate(total); err != nil {
+		out.Close()
+		return m.fail(ctx, p, cb, err)
+	}
+	if err := savePartMeta(t.metaPath, meta); err != nil {
+		out.Close()
+		return m.fail(ctx, p, cb, err)
+	}
+
+	initial := meta.segmentsDone()
+	p.BytesTotal = total
+	p.BytesDone = initial
+	if initial > 0 && m.console != nil {
+		m.console.LogResuming(t.filename, initial)
+	}
+
+	// mu guards meta.Segments, p and calls to cb, which all segments share.
+	var mu sync.Mutex
+	var sums *prefixHasher
+	if t.verify {
+		sums = newPrefixHasher(out, func() int64 {
+			mu.Lock()
+			defer mu.Unlock()
+			return meta.contiguous(total)
+		})
+	}
+	var speed speedMeter
+	lastSave := time.Now()
+	onWrite := func(i int, n int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		meta.Segments[i].Done += n
+		p.BytesDone += n
+		p.Speed = int64(speed.add(n))
+		p.ETA = transferETA(p, initial, t.start)
+		cb(*p)
+		if time.Since(lastSave) >= metaSaveInterval {
+			savePartMeta(t.metaPath, meta)
+			lastSave = time.Now()
+		}
+		if sums != nil {
+			sums.poke()
+		}
+	}
+
+	segCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for i := range meta.Segments {
+		if meta.Segments[i].complete() {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := m.fetchSegment(segCtx, t, out, &mu, &meta, i, onWrite); err != nil {
+				cancel(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if sums != nil {
+		h, err := sums.finish()
+		if err == nil && h.n == total {
+			t.hash = h
+		}
+		// Otherwise downloadFile hashes the whole file itself.
+	}
+	closeErr := out.Close()
+	mu.Lock()
+	saveErr := savePartMeta(t.metaPath, meta)
+	mu.Unlock()
+
+	if err := context.Cause(segCtx); err != nil && !errors.Is(err, context.Canceled) {
+		if errors.Is(err, errRemoteChanged) {
+			removePart(t.partPath)
+		}
+		return m.fail(ctx, p, cb, err)
+	}
+	if ctx.Err() != nil {
+		return m.fail(ctx, p, cb, ctx.Err())
+	}
+	if closeErr != nil {
+		return m.fail(ctx, p, cb, closeErr)
+	}
+	if saveErr != nil {
+		return m.fail(ctx, p, cb, saveErr)
+	}
+	if p.BytesDone != total {
+		return m.fail(ctx, p, cb, fmt.Errorf(
+			"incomplete download of %s: got %d of %d bytes", t.filename, p.BytesDone, total,
+		))
+	}
+	return nil
+}
+
+// prefixHasher hashes a file that segments fill out of order, front to back
+// as the run of finished bytes at its start grows. It runs alongside the
+// transfer, so verifying needs no second pass over the file at the end.
+type prefixHasher struct {
+	h      *multiHasher
+	f      *os.File
+	prefix func() int64 // how many bytes at the start of f are written
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan error
+}
+
+func newPrefixHasher(f *os.File, prefix func() int64) *prefixHasher {
+	ph := &prefixHasher{
+		h:      newMultiHasher(),
+		f:      f,
+		prefix: prefix,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan error, 1),
+	}
+	go ph.run()
+	// A resumed file may start with a finished prefix.
+	ph.poke()
+	return ph
+}
+
+// poke tells the hasher the prefix may have grown. It never blocks.
+func (ph *prefixHasher) poke() {
+	select {
+	case ph.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (ph *prefixHasher) run() {
+	var hashed int64
+	for {
+		stopping := false
+		select {
+		case <-ph.wake:
+		case <-ph.stop:
+			stopping = true
+		}
+		if to := ph.prefix(); to > hashed {
+			n, err := io.Copy(ph.h, io.NewSectionReader(ph.f, hashed, to-hashed))
+			hashed += n
+			if err != nil {
+				ph.done <- err
+				return
+			}
+		}
+		if stopping {
+			ph.done <- nil
+			return
+		}
+	}
+}
+
+// finish hashes the rest of the prefix and stops the hasher. The digests
+// cover h.n bytes from the start of the file.
+func (ph *prefixHasher) finish() (*multiHasher, error) {
+	close(ph.stop)
+	return ph.h, <-ph.done
+}
+
+// fetchSegment downloads the unfinished tail of segment i, retrying on
+// transient errors. Each retry continues from the segment's own progress.
+func (m *Manager) fetchSegment(ctx context.Context, t *transfer, out *os.File, mu *sync.Mutex, meta *partMeta, i int, onWrite func(int, int64)) error {
+	policy := m.RetryPolicy()
+	var err error
+	for attempt := 1; attempt <= segmentAttempts; attempt++ {
+		mu.Lock()
+		seg := meta.Segments[i]
+		mu.Unlock()
+		if seg.complete() {
+			return nil
+		}
+
+		err = m.fetchRange(ctx, t, out, meta, seg, func(n int64) { onWrite(i, n) })
+		// A stalled mirror fails the whole file, so it moves to the next.
+		if err == nil || ctx.Err() != nil || errors.Is(err, errRemoteChanged) || errors.Is(err, ErrStalled) || !policy.Retryable(err) {
+			return err
+		}
+		if attempt < segmentAttempts {
+			if m.console != nil {
+				m.console.Log(fmt.Sprintf("Segment %d of %s failed (%v), retrying.", i+1, t.filename, err))
+			}
+			if werr := util.SleepCtx(ctx, policy.Delay(attempt, err)); werr != nil {
+				return werr
+			}
+		}
+	}
+	return err
+}
+
+// fetchRange requests the bytes of seg not yet written and copies them into
+// out at their offset.
+func (m *Manager) fetchRange(ctx context.Context, t *transfer, out *os.File, meta *partMeta, seg segment, wrote func(int64)) error {
+	from := seg.Start + seg.Done
+	reqCtx, guard, release := newStallGuard(ctx)
+	defer release()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, t.src, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, seg.End-1))
+	if v := meta.ifRangeValidator(); v != "" {
+		req.Header.Set("If-Range", v)
+	}
+
+	resp, err := m.client.Do(req)
+	guard.got()
+	if err != nil {
+		return stalled(reqCtx, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// If-Range failed: the server is sending a different file.
+		return errRemoteChanged
+	default:
+		return util.NewHTTPStatusError(resp)
+	}
+
+	rs, _, rt, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return err
+	}
+	if rs != from || rt != meta.Total {
+		return errRemoteChanged
+	}
+
+	off := from
+	buf := make([]byte, 32*1024)
+	for off < seg.End {
+		guard.wait()
+		n, rerr := resp.Body.Read(buf)
+		guard.got()
+		if n > 0 {
+			if off+int64(n) > seg.End {
+				n = int(seg.End - off)
+			}
+			if _, werr := out.WriteAt(buf[:n], off); werr != nil {
+				return werr
+			}
+			off += int64(n)
+			wrote(int64(n))
+			// Segments share the global and per-job limits like any
+			// other transfer, so N connections don't mean N times the cap.
+			if werr := m.throttle(ctx, n); werr != nil {
+				return werr
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return stalled(reqCtx, rerr)
+		}
+	}
+	if off < seg.End {
+		return fmt.Errorf("segment ended early at %d of %d", off, seg.End)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// internal/download/stall.go
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// stallTimeout is how long a request may wait for a response or for its
+// next bytes before it's given up on and the file tried on another mirror.
+const stallTimeout = 30 * time.Second
+
+// ErrStalled means the server stopped answering in the middle of a request.
+var ErrStalled = fmt.Errorf("transfer stalled: nothing received for %s", stallTimeout)
+
+// stallGuard cancels a request's context with ErrStalled once it has waited
+// on the server for stallTimeout. It's armed from creation until got, and
+// again from every wait, so time spent in the rate limiter doesn't count.
+type stallGuard struct {
+	timer *time.Timer
+}
+
+// newStallGuard returns a context for a request, guarded from now on, and a
+// func that releases both.
+func newStallGuard(ctx context.Context) (context.Context, *stallGuard, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &stallGuard{timer: time.AfterFunc(stallTimeout, func() { cancel(ErrStalled) })}
+	return ctx, g, func() {
+		g.timer.Stop()
+		cancel(nil)
+	}
+}
+
+// wait arms the guard before reading from the server.
+func (g *stallGuard) wait() { g.timer.Reset(stallTimeout) }
+
+// got disarms it once the server has answered.
+func (g *stallGuard) got() { g.timer.Stop() }
+
+// stalled returns ErrStalled in place of err when the guard of ctx gave up
+// on the request; the error a cancelled read returns doesn't say.
+func stalled(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), ErrStalled) {
+		return ErrStalled
+	}
+	return err
+}
@@ -0,0 +1,240 @@
+// internal/jobstore/store.go
+package jobstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/download"
+)
+
+// saveInterval limits how often progress-only changes hit the disk. State
+// changes are always written straight away.
+const saveInterval = 2 * time.Second
+
+// Record is the persisted form of one download job.
+type Record struct {
+	URL        string    `json:"url"`
+	Name       string    `json:"name"`
+	TargetDir  string    `json:"target_dir"`
+	State      string    `json:"state"`
+	BytesDone  int64     `json:"bytes_done"`
+	BytesTotal int64     `json:"bytes_total"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error,omitempty"`
+	Added      time.Time `json:"added"`
+	Updated    time.Time `json:"updated"`
+	Finished   time.Time `json:"finished"`
+}
+
+// Key identifies a record: one URL downloaded into one directory.
+func (r Record) Key() string {
+	return r.URL + "\x00" + r.TargetDir
+}
+
+// Unfinished reports whether the job still has work left. "running" counts,
+// since that's what a job looks like when the app was closed mid-transfer.
+func (r Record) Unfinished() bool {
+	switch r.State {
+	case download.JobQueued.String(), download.JobRunning.String(), download.JobPaused.String():
+		return true
+	}
+	return false
+}
+
+// Paused reports whether the job was paused by the user.
+func (r Record) Paused() bool {
+	return r.State == download.JobPaused.String()
+}
+
+// Store keeps download records in a JSON file so the queue outlives the app.
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	records  map[string]Record
+	dirty    bool
+	lastSave time.Time
+}
+
+// DefaultPath returns jobs.json under the user's config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("user config dir: %w", err)
+	}
+	return filepath.Join(dir, "myrient-downloader", "jobs.json"), nil
+}
+
+// Open loads the store at path. A missing file is an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		records: map[string]Record{},
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var recs []Record
+	if err := json.Unmarshal(b, &recs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for _, r := range recs {
+		s.records[r.Key()] = r
+	}
+	return s, nil
+}
+
+// Track records the current state of j.
+func (s *Store) Track(j *download.Job) error {
+	p := j.Progress()
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := Record{
+		URL:        j.URL,
+		Name:       j.Name,
+		TargetDir:  j.TargetDir,
+		State:      j.State().String(),
+		BytesDone:  p.BytesDone,
+		BytesTotal: p.BytesTotal,
+		Attempts:   j.Attempts(),
+		Added:      now,
+		Updated:    now,
+	}
+	if err := j.Err(); err != nil {
+		r.LastError = err.Error()
+	}
+	if j.State().Finished() {
+		r.Finished = now
+	}
+
+	prev, seen := s.records[r.Key()]
+	if seen && prev.Unfinished() {
+		r.Added = prev.Added
+		// A restored job has no progress until it runs again; keep the
+		// last known numbers so the record stays meaningful meanwhile.
+		if r.BytesTotal == 0 {
+			r.BytesDone, r.BytesTotal = prev.BytesDone, prev.BytesTotal
+		}
+	}
+	if seen && prev.State == r.State && !prev.Finished.IsZero() {
+		r.Finished = prev.Finished
+	}
+
+	s.records[r.Key()] = r
+	s.dirty = true
+
+	if seen && prev.State == r.State && now.Sub(s.lastSave) < saveInterval {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// Unfinished returns records that were queued, running or paused, oldest first.
+func (s *Store) Unfinished() []Record {
+	return s.filter(func(r Record) bool { return r.Unfinished() }, false)
+}
+
+// History returns finished records, most recent first.
+func (s *Store) History() []Record {
+	return s.filter(func(r Record) bool { return !r.Unfinished() }, true)
+}
+
+// Discard marks unfinished records as cancelled so they're not offered again.
+func (s *Store) Discard(recs []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, r := range recs {
+		r.State = download.JobCancelled.String()
+		r.Updated = now
+		r.Finished = now
+		s.records[r.Key()] = r
+	}
+	s.dirty = true
+	return s.saveLocked()
+}
+
+// ClearHistory removes every finished record.
+func (s *Store) ClearHistory() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, r := range s.records {
+		if !r.Unfinished() {
+			delete(s.records, k)
+		}
+	}
+	s.dirty = true
+	return s.saveLocked()
+}
+
+// Flush writes pending changes to disk.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+func (s *Store) filter(keep func(Record) bool, newestFirst bool) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Record
+	for _, r := range s.records {
+		if keep(r) {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if newestFirst {
+			return out[i].Updated.After(out[j].Updated)
+		}
+		return out[i].Added.Before(out[j].Added)
+	})
+	return out
+}
+
+// saveLocked writes all records atomically via a temp file. s.mu must be held.
+func (s *Store) saveLocked() error {
+	recs := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		recs = append(recs, r)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].Added.Before(recs[j].Added) })
+
+	b, err := json.MarshalIndent(recs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("mkdir %s: %w", filepath.Dir(s.path), err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp, err)
+	}
+	s.dirty = false
+	s.lastSave = time.Now()
+	return nil
+}
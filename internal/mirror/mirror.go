@@ -0,0 +1,255 @@
+// internal/mirror/mirror.go
+package mirror
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWeight is how much a new sample moves the latency average.
+	latencyWeight = 0.3
+
+	// errorWeight is how much a new result moves the error rate.
+	errorWeight = 0.2
+
+	// baseCooldown is how long a mirror is avoided after a failure; it
+	// grows with every failure in a row, up to maxCooldown.
+	baseCooldown = 30 * time.Second
+	maxCooldown  = 10 * time.Minute
+)
+
+// Stats describes how a mirror has been doing.
+type Stats struct {
+	Base      string
+	Requests  int
+	Failures  int
+	Latency   time.Duration // average time to response headers
+	ErrorRate float64       // recent share of failed requests, 0..1
+	Down      bool          // failed recently and is being avoided
+}
+
+type mirror struct {
+	base      string
+	requests  int
+	failures  int
+	inARow    int
+	lastFail  time.Time
+	latency   time.Duration
+	errorRate float64
+}
+
+// score ranks mirrors for new requests; lower is better. A mirror in its
+// cooldown ranks below every healthy one but is still tried as a last resort.
+func (m *mirror) score(now time.Time) float64 {
+	s := float64(m.latency.Milliseconds()) * (1 + 4*m.errorRate)
+	if m.down(now) {
+		s += 1e9
+	}
+	return s
+}
+
+func (m *mirror) down(now time.Time) bool {
+	if m.inARow == 0 {
+		return false
+	}
+	cooldown := baseCooldown * time.Duration(m.inARow)
+	if cooldown > maxCooldown {
+		cooldown = maxCooldown
+	}
+	return now.Sub(m.lastFail) < cooldown
+}
+
+// Set is a group of base URLs serving the same tree, e.g.
+// "https://myrient.erista.me/files/" and a local copy. Any URL under one of
+// them can be rewritten onto the others. It's safe for concurrent use.
+type Set struct {
+	mu      sync.Mutex
+	mirrors []*mirror
+}
+
+// New returns a set of the given base URLs, in order of preference while
+// nothing is known about them yet.
+func New(bases []string) *Set {
+	s := &Set{}
+	s.SetBases(bases)
+	return s
+}
+
+// SetBases replaces the configured mirrors. Stats are kept for bases that
+// stay in the list.
+func (s *Set) SetBases(bases []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := map[string]*mirror{}
+	for _, m := range s.mirrors {
+		old[m.base] = m
+	}
+	s.mirrors = s.mirrors[:0]
+	for _, b := range bases {
+		b = normalize(b)
+		if b == "" {
+			continue
+		}
+		m := old[b]
+		if m == nil {
+			m = &mirror{base: b}
+		}
+		delete(old, b)
+		s.mirrors = append(s.mirrors, m)
+	}
+}
+
+// Bases returns the configured base URLs in configured order.
+func (s *Set) Bases() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, len(s.mirrors))
+	for i, m := range s.mirrors {
+		out[i] = m.base
+	}
+	return out
+}
+
+// Len returns the number of configured mirrors.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.mirrors)
+}
+
+// Candidates returns rawURL rewritten onto every mirror, healthiest first.
+// A URL that isn't under any mirror is returned on its own. A nil Set
+// always returns just rawURL.
+func (s *Set) Candidates(rawURL string) []string {
+	if s == nil {
+		return []string{rawURL}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	own, rel := s.matchLocked(rawURL)
+	if own == nil {
+		return []string{rawURL}
+	}
+
+	now := time.Now()
+	ranked := append([]*mirror(nil), s.mirrors...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score(now) < ranked[j].score(now)
+	})
+	out := make([]string, len(ranked))
+	for i, m := range ranked {
+		out[i] = m.base + rel
+	}
+	return out
+}
+
+// Report records the outcome of a request to rawURL: how long the response
+// headers took and whether it failed. Cancelled requests say nothing about
+// the mirror and are ignored, as are URLs outside the set.
+func (s *Set) Report(rawURL string, latency time.Duration, err error) {
+	if s == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, _ := s.matchLocked(rawURL)
+	if m == nil {
+		return
+	}
+	m.requests++
+	if err != nil {
+		m.failures++
+		m.inARow++
+		m.lastFail = time.Now()
+		m.errorRate += errorWeight * (1 - m.errorRate)
+		return
+	}
+	m.inARow = 0
+	m.errorRate -= errorWeight * m.errorRate
+	if m.latency == 0 {
+		m.latency = latency
+	} else {
+		m.latency += time.Duration(latencyWeight * float64(latency-m.latency))
+	}
+}
+
+// Rebase moves rawURL onto the mirror that like is under, e.g. to present
+// entries listed from a fallback mirror as if they came from the one asked
+// for. URLs outside the set come back unchanged.
+func (s *Set) Rebase(rawURL, like string) string {
+	if s == nil {
+		return rawURL
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	from, rel := s.matchLocked(rawURL)
+	to, _ := s.matchLocked(like)
+	if from == nil || to == nil {
+		return rawURL
+	}
+	return to.base + rel
+}
+
+// Stats returns a snapshot of every mirror's health in configured order.
+func (s *Set) Stats() []Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	out := make([]Stats, len(s.mirrors))
+	for i, m := range s.mirrors {
+		out[i] = Stats{
+			Base:      m.base,
+			Requests:  m.requests,
+			Failures:  m.failures,
+			Latency:   m.latency,
+			ErrorRate: m.errorRate,
+			Down:      m.down(now),
+		}
+	}
+	return out
+}
+
+// matchLocked finds the mirror rawURL lives under, preferring the longest
+// base, and returns the path below it. s.mu must be held.
+func (s *Set) matchLocked(rawURL string) (*mirror, string) {
+	var best *mirror
+	for _, m := range s.mirrors {
+		if strings.HasPrefix(rawURL, m.base) && (best == nil || len(m.base) > len(best.base)) {
+			best = m
+		}
+	}
+	if best == nil {
+		// The base itself, without its trailing slash.
+		for _, m := range s.mirrors {
+			if rawURL == strings.TrimSuffix(m.base, "/") {
+				return m, ""
+			}
+		}
+		return nil, ""
+	}
+	return best, strings.TrimPrefix(rawURL, best.base)
+}
+
+// normalize trims b and makes sure it ends in a slash, so a base only
+// matches whole path segments.
+func normalize(b string) string {
+	b = strings.TrimSpace(b)
+	if b == "" {
+		return ""
+	}
+	if !strings.HasSuffix(b, "/") {
+		b += "/"
+	}
+	return b
+}
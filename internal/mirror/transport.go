@@ -0,0 +1,35 @@
+// internal/mirror/transport.go
+package mirror
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Transport is an http.RoundTripper that reports every request's latency
+// and outcome to the Set returned by Mirrors, which uses them to rank the
+// mirrors. Mirrors may return nil, in which case nothing is recorded.
+type Transport struct {
+	Next    http.RoundTripper
+	Mirrors func() *Set
+}
+
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	if set := t.Mirrors(); set != nil {
+		failed := err
+		if err == nil && (resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests) {
+			// Anything else, a 404 included, is an answer: the file may be
+			// missing everywhere, which says nothing about the mirror.
+			failed = fmt.Errorf("http status: %s", resp.Status)
+		}
+		set.Report(req.URL.String(), time.Since(start), failed)
+	}
+	return resp, err
+}
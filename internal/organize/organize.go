@@ -0,0 +1,253 @@
+// internal/organize/organize.go
+package organize
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/sysmap"
+	"awesomeProject1/internal/util"
+)
+
+// maxDepth is how deep system folders are looked for; a profile's deepest
+// are three levels down, like Assets/snes/common.
+const maxDepth = 3
+
+// Move is a system folder whose contents go where the profile wants them.
+// Paths are slash separated and relative to the library folder.
+type Move struct {
+	System   sysmap.System
+	From, To string
+}
+
+// Plan is what it takes to bring an existing library in line with a
+// profile. Nothing changes on disk until Apply.
+type Plan struct {
+	// Dir is the library folder.
+	Dir string
+
+	Profile *sysmap.Profile
+
+	// Moves are the system folders in the wrong place.
+	Moves []Move
+
+	// Unzip are archives the profile wants unpacked, relative to Dir as
+	// they will be after the moves.
+	Unzip []string
+
+	// Skipped says why folders that look like system folders are left
+	// alone.
+	Skipped []string
+}
+
+// PlanLibrary looks for system folders in dir, named after any profile,
+// system ID or Myrient folder, and plans moving them to where p puts them.
+// Archives the profile unpacks are unpacked too; those it keeps, once
+// unpacked, stay that way.
+func PlanLibrary(dir string, p *sysmap.Profile) (*Plan, error) {
+	plan := &Plan{Dir: dir, Profile: p}
+	// Folders merged into the same place may hold the same archive.
+	unzip := map[string]bool{}
+	err := filepath.WalkDir(dir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || fp == dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(dir, fp)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		systems := sysmap.DetectFolder(rel)
+		switch {
+		case len(systems) > 1:
+			var ids []string
+			for _, s := range systems {
+				ids = append(ids, s.ID)
+			}
+			plan.Skipped = append(plan.Skipped, fmt.Sprintf("%s: could hold any of %s", rel, strings.Join(ids, ", ")))
+			return filepath.SkipDir
+		case len(systems) == 0:
+			if strings.Count(rel, "/")+1 >= maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		s := systems[0]
+		to := p.Folder(s)
+		if rel != to {
+			if strings.HasPrefix(to+"/", rel+"/") {
+				plan.Skipped = append(plan.Skipped, fmt.Sprintf("%s: %s would go inside it", rel, to))
+				return filepath.SkipDir
+			}
+			plan.Moves = append(plan.Moves, Move{System: s, From: rel, To: to})
+		}
+		if p.Unzip(s) {
+			zips, err := archivesIn(fp)
+			if err != nil {
+				return err
+			}
+			for _, z := range zips {
+				if z = path.Join(to, z); !unzip[z] {
+					unzip[z] = true
+					plan.Unzip = append(plan.Unzip, z)
+				}
+			}
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// archivesIn lists the .zip files below dir, relative to it.
+func archivesIn(dir string) ([]string, error) {
+	var zips []string
+	err := filepath.WalkDir(dir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(fp), ".zip") {
+			rel, err := filepath.Rel(dir, fp)
+			if err != nil {
+				return err
+			}
+			zips = append(zips, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return zips, err
+}
+
+// Empty reports whether the plan has nothing to do.
+func (pl *Plan) Empty() bool {
+	return len(pl.Moves) == 0 && len(pl.Unzip) == 0
+}
+
+// Summary is a one-line account of the plan.
+func (pl *Plan) Summary() string {
+	text := fmt.Sprintf("%s layout: %d folders to move, %d archives to unpack", pl.Profile.Name, len(pl.Moves), len(pl.Unzip))
+	if len(pl.Skipped) > 0 {
+		text += fmt.Sprintf(", %d folders left alone", len(pl.Skipped))
+	}
+	return text
+}
+
+// Lines describes each step of the plan.
+func (pl *Plan) Lines() []string {
+	var lines []string
+	for _, m := range pl.Moves {
+		lines = append(lines, fmt.Sprintf("move   %s → %s", m.From, m.To))
+	}
+	for _, z := range pl.Unzip {
+		lines = append(lines, "unpack "+z)
+	}
+	for _, s := range pl.Skipped {
+		lines = append(lines, "skip   "+s)
+	}
+	return lines
+}
+
+// Apply carries out the plan, logging each step. It goes on past failures,
+// returning them all; files that would overwrite others stay where they are.
+func (pl *Plan) Apply(log func(string)) error {
+	var errs []error
+	for _, m := range pl.Moves {
+		from := filepath.Join(pl.Dir, filepath.FromSlash(m.From))
+		to := filepath.Join(pl.Dir, filepath.FromSlash(m.To))
+		if err := moveInto(from, to); err != nil {
+			errs = append(errs, fmt.Errorf("move %s: %w", m.From, err))
+			log(fmt.Sprintf("Moving %s failed: %v", m.From, err))
+			continue
+		}
+		removeEmptyParents(pl.Dir, filepath.Dir(from))
+		log(fmt.Sprintf("Moved %s to %s", m.From, m.To))
+	}
+	for _, z := range pl.Unzip {
+		zp := filepath.Join(pl.Dir, filepath.FromSlash(z))
+		if _, err := os.Stat(zp); err != nil {
+			// Left behind by a failed move; that's been reported.
+			continue
+		}
+		if _, err := util.UnzipZipFileInPlace(zp); err != nil {
+			errs = append(errs, fmt.Errorf("unpack %s: %w", z, err))
+			log(fmt.Sprintf("Unpacking %s failed: %v", z, err))
+			continue
+		}
+		log("Unpacked " + z)
+	}
+	return errors.Join(errs...)
+}
+
+// moveInto moves the folder from to to, merging it into to if that exists.
+// Files already in to are kept and the clashing ones reported.
+func moveInto(from, to string) error {
+	toInfo, err := os.Stat(to)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return err
+		}
+		return os.Rename(from, to)
+	}
+	if err != nil {
+		return err
+	}
+	if fromInfo, err := os.Stat(from); err == nil && os.SameFile(fromInfo, toInfo) {
+		// Only the case differs, on a file system that ignores it.
+		tmp := from + ".organize"
+		if err := os.Rename(from, tmp); err != nil {
+			return err
+		}
+		return os.Rename(tmp, to)
+	}
+	if !toInfo.IsDir() {
+		return fmt.Errorf("%s is a file", to)
+	}
+
+	entries, err := os.ReadDir(from)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		src, dst := filepath.Join(from, e.Name()), filepath.Join(to, e.Name())
+		if _, err := os.Lstat(dst); err == nil {
+			if e.IsDir() {
+				errs = append(errs, moveInto(src, dst))
+			} else {
+				errs = append(errs, fmt.Errorf("%s already exists, left %s in place", dst, src))
+			}
+			continue
+		}
+		errs = append(errs, os.Rename(src, dst))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// removeEmptyParents removes dir and its parents while they're empty,
+// stopping at root.
+func removeEmptyParents(root, dir string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
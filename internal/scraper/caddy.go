@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/util"
+
+	"golang.org/x/net/html"
+)
+
+// CaddyParser reads the HTML listing of Caddy's file_server browse. It has
+// exact byte sizes and timestamps in attributes, unlike its visible text.
+type CaddyParser struct{}
+
+func (CaddyParser) Name() string { return "caddy" }
+
+func (CaddyParser) Detect(contentType string, body []byte) bool {
+	return bytes.Contains(body, []byte("caddyserver.com")) || bytes.Contains(body, []byte(`data-size="`))
+}
+
+func (CaddyParser) Parse(base *url.URL, body []byte) ([]domain.FileEntry, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	var entries []domain.FileEntry
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			if fe, ok := caddyRow(base, n); ok {
+				entries = append(entries, fe)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return entries, nil
+}
+
+// caddyRow reads one <tr>; header rows and rows without a link are skipped.
+func caddyRow(base *url.URL, tr *html.Node) (domain.FileEntry, bool) {
+	var fe domain.FileEntry
+	var link, name *html.Node
+	var sizeAttr string
+
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "th":
+				return
+			case n.Data == "a" && link == nil:
+				link = n
+			case hasClass(n, "name") && name == nil:
+				name = n
+			case n.Data == "td" && hasClass(n, "size"):
+				sizeAttr = attr(n, "data-size")
+			case n.Data == "time":
+				if t, ok := util.ParseListingDate(attr(n, "datetime")); ok {
+					fe.Modified = t
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(tr)
+
+	if link == nil {
+		return fe, false
+	}
+	href := attr(link, "href")
+	if href == "" || strings.HasPrefix(href, "?") {
+		return fe, false
+	}
+	rel, err := url.Parse(href)
+	if err != nil {
+		return fe, false
+	}
+
+	fe.URL = base.ResolveReference(rel).String()
+	fe.IsDir = strings.HasSuffix(href, "/") || href == ".."
+	if href == ".." {
+		// Caddy's "Up" row.
+		fe.Name = "Parent directory/"
+		return fe, true
+	}
+	if name == nil {
+		name = link
+	}
+	fe.Name = strings.TrimSpace(nodeText(name))
+	if fe.Name == "" {
+		return fe, false
+	}
+	if fe.IsDir && !strings.HasSuffix(fe.Name, "/") {
+		fe.Name += "/"
+	}
+	if size, err := strconv.ParseInt(sizeAttr, 10, 64); err == nil && !fe.IsDir {
+		fe.Size = size
+	}
+	return fe, true
+}
@@ -1,30 +1,68 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
+	"sync/atomic"
+	"time"
 
 	"awesomeProject1/internal/domain"
-
-	"golang.org/x/net/html"
+	"awesomeProject1/internal/mirror"
+	"awesomeProject1/internal/util"
 )
 
-// HTTPIndex fetches and parses simple HTML directory indexes (like Myrient).
+// HTTPIndex fetches and parses directory indexes (like Myrient). The page
+// format is detected for every page unless fixed with SetParser.
 type HTTPIndex struct {
-	client *http.Client
+	client  *http.Client
+	parser  atomic.Pointer[IndexParser]
+	retry   atomic.Pointer[util.RetryPolicy]
+	mirrors atomic.Pointer[mirror.Set]
 }
 
 func NewHTTPIndex() *HTTPIndex {
-	return &HTTPIndex{
-		client: &http.Client{},
+	// Listing is interactive, so give up sooner than downloads do.
+	retry := util.DefaultRetryPolicy()
+	retry.MaxAttempts = 3
+	retry.MaxDelay = 10 * time.Second
+
+	h := &HTTPIndex{}
+	h.retry.Store(&retry)
+	h.client = &http.Client{
+		Transport: mirror.Transport{Mirrors: h.mirrors.Load},
 	}
+	return h
+}
+
+// SetMirrors sets mirrors to list from; nil disables failover. A page under
+// one of them is fetched from the healthiest mirror that answers, and the
+// entries' URLs are put back onto the mirror that was asked for.
+func (h *HTTPIndex) SetMirrors(s *mirror.Set) {
+	h.mirrors.Store(s)
+}
+
+// SetRetryPolicy sets how failed page requests are retried. It applies to
+// the next listing, including the next page of a running walk.
+func (h *HTTPIndex) SetRetryPolicy(p util.RetryPolicy) {
+	h.retry.Store(&p)
+}
+
+// SetParser fixes the index format instead of detecting it; nil goes back to
+// detection. It applies from the next page fetched, also while listing.
+func (h *HTTPIndex) SetParser(p IndexParser) {
+	h.parser.Store(&p)
 }
 
 // List returns all file/directory entries found at the given URL.
 func (h *HTTPIndex) List(rawURL string) ([]domain.FileEntry, error) {
+	return h.ListCtx(context.Background(), rawURL)
+}
+
+// ListCtx is List with cancellation; the request is aborted when ctx is done.
+func (h *HTTPIndex) ListCtx(ctx context.Context, rawURL string) ([]domain.FileEntry, error) {
 	if rawURL == "" {
 		return nil, fmt.Errorf("empty URL")
 	}
@@ -36,7 +74,45 @@ func (h *HTTPIndex) List(rawURL string) ([]domain.FileEntry, error) {
 		u.Scheme = "https"
 	}
 
-	resp, err := h.client.Get(u.String())
+	want := u.String()
+	mirrors := h.mirrors.Load()
+
+	// Every attempt goes through all mirrors, healthiest first.
+	var entries []domain.FileEntry
+	err = h.retry.Load().Do(ctx, func(int) error {
+		var lastErr error
+		for _, src := range mirrors.Candidates(want) {
+			es, err := h.fetch(ctx, src)
+			if err != nil {
+				lastErr = err
+				if ctx.Err() != nil {
+					break
+				}
+				continue
+			}
+			if src != want {
+				for i := range es {
+					es[i].URL = mirrors.Rebase(es[i].URL, want)
+				}
+			}
+			entries = es
+			return nil
+		}
+		return lastErr
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// fetch lists a single page.
+func (h *HTTPIndex) fetch(ctx context.Context, rawURL string) ([]domain.FileEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	resp, err := h.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("http get: %w", err)
 	}
@@ -44,7 +120,7 @@ func (h *HTTPIndex) List(rawURL string) ([]domain.FileEntry, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		io.Copy(io.Discard, resp.Body)
-		return nil, fmt.Errorf("http status: %s", resp.Status)
+		return nil, util.NewHTTPStatusError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -52,91 +128,14 @@ func (h *HTTPIndex) List(rawURL string) ([]domain.FileEntry, error) {
 		return nil, fmt.Errorf("read body: %w", err)
 	}
 
-	doc, err := html.Parse(strings.NewReader(string(body)))
-	if err != nil {
-		return nil, fmt.Errorf("parse html: %w", err)
-	}
-
-	var entries []domain.FileEntry
-
-	var walk func(*html.Node)
-	walk = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "a" {
-			// Only consider anchors that live inside <td> (skip header <th> sort links).
-			if n.Parent == nil || n.Parent.Type != html.ElementNode || n.Parent.Data != "td" {
-				// Not in a data cell, skip (these are usually header arrows etc.).
-				// walk children anyway
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					walk(c)
-				}
-				return
-			}
-
-			href := ""
-			for _, a := range n.Attr {
-				if a.Key == "href" {
-					href = a.Val
-					break
-				}
-			}
-			if href == "" {
-				// no target, skip
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					walk(c)
-				}
-				return
-			}
-
-			name := strings.TrimSpace(nodeText(n))
-			if name == "" {
-				// empty label, skip
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					walk(c)
-				}
-				return
-			}
-
-			// Resolve relative URLs
-			rel, err := url.Parse(href)
-			if err != nil {
-				// bad href, skip
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					walk(c)
-				}
-				return
-			}
-			abs := u.ResolveReference(rel)
-
-			isDir := strings.HasSuffix(href, "/") || strings.HasSuffix(name, "/")
-
-			entries = append(entries, domain.FileEntry{
-				Name:  name,
-				URL:   abs.String(),
-				IsDir: isDir,
-			})
-		}
-
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			walk(c)
-		}
+	var parser IndexParser
+	if p := h.parser.Load(); p != nil {
+		parser = *p
 	}
-	walk(doc)
-
-	return entries, nil
-}
-
-// nodeText returns all concatenated text nodes under n.
-func nodeText(n *html.Node) string {
-	var b strings.Builder
-	var rec func(*html.Node)
-	rec = func(n *html.Node) {
-		if n.Type == html.TextNode {
-			b.WriteString(n.Data)
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			rec(c)
-		}
+	if parser == nil {
+		parser = DetectParser(resp.Header.Get("Content-Type"), body)
 	}
-	rec(n)
-	return b.String()
+	// Resolve links against the final URL, in case of a redirect such as
+	// the one adding a missing trailing slash.
+	return parser.Parse(resp.Request.URL, body)
 }
@@ -0,0 +1,83 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/util"
+)
+
+// JSONParser reads JSON listings: nginx's autoindex_format json and Caddy's
+// file_server browse when asked for application/json.
+type JSONParser struct{}
+
+// jsonEntry covers the fields of both servers.
+type jsonEntry struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+
+	// nginx
+	Type  string `json:"type"` // "directory", "file" or "other"
+	MTime string `json:"mtime"`
+
+	// Caddy
+	URL     string `json:"url"`
+	ModTime string `json:"mod_time"`
+	IsDir   bool   `json:"is_dir"`
+}
+
+func (JSONParser) Name() string { return "json" }
+
+func (JSONParser) Detect(contentType string, body []byte) bool {
+	return strings.Contains(contentType, "json") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+}
+
+func (JSONParser) Parse(base *url.URL, body []byte) ([]domain.FileEntry, error) {
+	var raw []jsonEntry
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+
+	entries := make([]domain.FileEntry, 0, len(raw))
+	for _, je := range raw {
+		name := strings.TrimSuffix(je.Name, "/")
+		if name == "" {
+			continue
+		}
+		isDir := je.IsDir || je.Type == "directory"
+
+		ref := &url.URL{Path: name}
+		if je.URL != "" {
+			u, err := url.Parse(je.URL)
+			if err != nil {
+				continue
+			}
+			ref = u
+		} else if isDir {
+			ref.Path += "/"
+		}
+
+		fe := domain.FileEntry{
+			Name:  name,
+			URL:   base.ResolveReference(ref).String(),
+			IsDir: isDir,
+		}
+		if isDir {
+			fe.Name += "/"
+		} else {
+			fe.Size = je.Size
+		}
+		for _, ts := range []string{je.MTime, je.ModTime} {
+			if t, ok := util.ParseListingDate(ts); ok {
+				fe.Modified = t
+				break
+			}
+		}
+		entries = append(entries, fe)
+	}
+	return entries, nil
+}
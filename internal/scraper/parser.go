@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+
+	"golang.org/x/net/html"
+)
+
+// IndexParser turns one directory index page into entries.
+type IndexParser interface {
+	// Name identifies the format, e.g. "nginx-json".
+	Name() string
+
+	// Detect reports whether a page with this Content-Type and body looks
+	// like the parser's format.
+	Detect(contentType string, body []byte) bool
+
+	// Parse extracts the page's entries, resolving links against base.
+	Parse(base *url.URL, body []byte) ([]domain.FileEntry, error)
+}
+
+// Parsers lists the known formats in the order DetectParser tries them,
+// most specific first.
+var Parsers = []IndexParser{
+	JSONParser{},
+	CaddyParser{},
+	TableParser{},
+	PreParser{},
+}
+
+// DetectParser returns the first of Parsers that recognises the page, or
+// TableParser if none does.
+func DetectParser(contentType string, body []byte) IndexParser {
+	for _, p := range Parsers {
+		if p.Detect(contentType, body) {
+			return p
+		}
+	}
+	return TableParser{}
+}
+
+// ParserByName returns the parser from Parsers with the given name.
+func ParserByName(name string) (IndexParser, bool) {
+	for _, p := range Parsers {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// nodeText returns all concatenated text nodes under n.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var rec func(*html.Node)
+	rec = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			rec(c)
+		}
+	}
+	rec(n)
+	return b.String()
+}
+
+// linkName returns the name a link's text gives, or the full one from its
+// href, resolved to abs, when the text was cut short ("..>" or "…").
+func linkName(text, href string, abs *url.URL) string {
+	if !strings.HasSuffix(text, "..>") && !strings.HasSuffix(text, "…") {
+		return text
+	}
+	name := path.Base(strings.TrimSuffix(abs.Path, "/"))
+	if strings.HasSuffix(href, "/") {
+		name += "/"
+	}
+	return name
+}
+
+// attr returns the value of n's attribute key, or "".
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasClass reports whether n's class attribute includes class.
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
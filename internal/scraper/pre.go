@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/util"
+
+	"golang.org/x/net/html"
+)
+
+// PreParser reads plain-text listings inside <pre>, as written by nginx's
+// autoindex and Apache's FancyIndexing: a link per line followed by the
+// date and size.
+type PreParser struct{}
+
+func (PreParser) Name() string { return "pre" }
+
+func (PreParser) Detect(contentType string, body []byte) bool {
+	return bytes.Contains(bytes.ToLower(body), []byte("<pre"))
+}
+
+func (PreParser) Parse(base *url.URL, body []byte) ([]domain.FileEntry, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	var entries []domain.FileEntry
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type != html.ElementNode || n.Data != "pre" {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "a" {
+				continue
+			}
+			// "?C=N;O=D" and friends are Apache's column sort links.
+			href := attr(c, "href")
+			if href == "" || strings.HasPrefix(href, "?") {
+				continue
+			}
+			rel, err := url.Parse(href)
+			if err != nil {
+				continue
+			}
+			abs := base.ResolveReference(rel)
+
+			name := strings.TrimSpace(nodeText(c))
+			name = linkName(name, href, abs)
+			if name == "" {
+				continue
+			}
+
+			fe := domain.FileEntry{
+				Name:  name,
+				URL:   abs.String(),
+				IsDir: strings.HasSuffix(href, "/") || strings.HasSuffix(name, "/"),
+			}
+			if fe.IsDir && !strings.HasSuffix(fe.Name, "/") {
+				fe.Name += "/"
+			}
+			lineDetails(lineAfter(c), &fe)
+			entries = append(entries, fe)
+		}
+	}
+	walk(doc)
+
+	return entries, nil
+}
+
+// lineAfter returns the text following n up to the end of its line.
+func lineAfter(n *html.Node) string {
+	var b strings.Builder
+	for c := n.NextSibling; c != nil; c = c.NextSibling {
+		if c.Type != html.TextNode {
+			if c.Type == html.ElementNode && (c.Data == "a" || c.Data == "img" || c.Data == "hr") {
+				break
+			}
+			continue
+		}
+		text, _, found := strings.Cut(c.Data, "\n")
+		b.WriteString(text)
+		if found {
+			break
+		}
+	}
+	return b.String()
+}
+
+// lineDetails fills in fe's date and size from the columns after a link,
+// e.g. "13-Dec-2024 19:55    1234" (nginx) or "2024-12-13 19:55  1.2M"
+// (Apache).
+func lineDetails(line string, fe *domain.FileEntry) {
+	fields := strings.Fields(line)
+	rest := fields
+	if len(fields) >= 2 {
+		if t, ok := util.ParseListingDate(fields[0] + " " + fields[1]); ok {
+			fe.Modified, rest = t, fields[2:]
+		}
+	}
+	if fe.Modified.IsZero() && len(fields) >= 1 {
+		if t, ok := util.ParseListingDate(fields[0]); ok {
+			fe.Modified, rest = t, fields[1:]
+		}
+	}
+	if len(rest) > 0 && !fe.IsDir {
+		if size, ok := util.ParseSize(rest[0]); ok {
+			fe.Size = size
+		}
+	}
+}
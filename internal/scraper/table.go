@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/util"
+
+	"golang.org/x/net/html"
+)
+
+// TableParser reads listings laid out as an HTML table with one link per
+// row: Myrient, Apache with HTMLTable, lighttpd's mod_dirlisting and most
+// hand-written indexes. Size and date come from the row's other cells.
+type TableParser struct{}
+
+func (TableParser) Name() string { return "table" }
+
+func (TableParser) Detect(contentType string, body []byte) bool {
+	return bytes.Contains(bytes.ToLower(body), []byte("<td"))
+}
+
+func (TableParser) Parse(base *url.URL, body []byte) ([]domain.FileEntry, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	var entries []domain.FileEntry
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			// Only consider anchors that live inside <td> (skip header <th> sort links).
+			if n.Parent == nil || n.Parent.Type != html.ElementNode || n.Parent.Data != "td" {
+				// Not in a data cell, skip (these are usually header arrows etc.).
+				// walk children anyway
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+				return
+			}
+
+			href := ""
+			for _, a := range n.Attr {
+				if a.Key == "href" {
+					href = a.Val
+					break
+				}
+			}
+			if href == "" {
+				// no target, skip
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+				return
+			}
+
+			name := strings.TrimSpace(nodeText(n))
+			if name == "" {
+				// empty label, skip
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+				return
+			}
+
+			// Resolve relative URLs
+			rel, err := url.Parse(href)
+			if err != nil {
+				// bad href, skip
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+				return
+			}
+			abs := base.ResolveReference(rel)
+			name = linkName(name, href, abs)
+
+			isDir := strings.HasSuffix(href, "/") || strings.HasSuffix(name, "/")
+			if isDir && !strings.HasSuffix(name, "/") {
+				// lighttpd puts the slash after the link.
+				name += "/"
+			}
+
+			fe := domain.FileEntry{
+				Name:  name,
+				URL:   abs.String(),
+				IsDir: isDir,
+			}
+			rowDetails(n.Parent, &fe)
+			entries = append(entries, fe)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return entries, nil
+}
+
+// rowDetails fills in fe's size and date from the <td> cells that follow
+// the link's cell in the same row. Dates are tried first, since a bare
+// number could pass for either.
+func rowDetails(cell *html.Node, fe *domain.FileEntry) {
+	for c := cell.NextSibling; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "td" {
+			continue
+		}
+		text := strings.TrimSpace(nodeText(c))
+		if t, ok := util.ParseListingDate(text); ok {
+			if fe.Modified.IsZero() {
+				fe.Modified = t
+			}
+			continue
+		}
+		if size, ok := util.ParseSize(text); ok && fe.Size == 0 && !fe.IsDir {
+			fe.Size = size
+		}
+	}
+}
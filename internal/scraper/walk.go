@@ -0,0 +1,238 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+)
+
+// defaultWalkWorkers is how many directory pages Walk fetches at once when
+// WalkOptions.Workers isn't set.
+const defaultWalkWorkers = 4
+
+// WalkOptions controls a recursive Walk.
+type WalkOptions struct {
+	// Workers is the number of directory pages fetched in parallel.
+	Workers int
+
+	// MaxDepth is the deepest level reported, the root's own entries being
+	// level 1. Directories at MaxDepth are reported but not entered.
+	// 0 means no limit.
+	MaxDepth int
+
+	// Include, if not empty, keeps only files whose name matches one of the
+	// patterns. Directories are always entered.
+	Include []string
+
+	// Exclude drops files and directories whose name matches one of the
+	// patterns; excluded directories are not entered.
+	Exclude []string
+}
+
+// WalkEntry is one file or directory found by Walk.
+type WalkEntry struct {
+	domain.FileEntry
+
+	// Path is the entry's path below the walk root, e.g. "Sub/Game.zip".
+	Path string
+
+	// Depth is 1 for the root's own entries, 2 for theirs, and so on.
+	Depth int
+}
+
+// WalkFunc is called by Walk for every entry, one call at a time.
+//
+// err is non-nil only when a directory that was reported earlier couldn't be
+// listed; returning nil then skips it and carries on. For a directory,
+// returning fs.SkipDir skips its contents. Returning fs.SkipAll stops the walk
+// without an error and any other error stops it and is returned by Walk.
+type WalkFunc func(e WalkEntry, err error) error
+
+type walkDir struct {
+	entry WalkEntry
+	url   *url.URL
+}
+
+type walkListing struct {
+	dir     walkDir
+	entries []domain.FileEntry
+	err     error
+}
+
+// Walk lists rootURL and every directory below it, fetching up to
+// opts.Workers pages in parallel, and hands each entry to fn as it's found.
+// Links that lead outside the root, back to a parent or to a page already
+// seen are never followed.
+func (h *HTTPIndex) Walk(ctx context.Context, rootURL string, opts WalkOptions, fn WalkFunc) error {
+	for _, p := range append(append([]string(nil), opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", p, err)
+		}
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = defaultWalkWorkers
+	}
+
+	root, err := url.Parse(rootURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if root.Scheme == "" {
+		root.Scheme = "https"
+	}
+	if !strings.HasSuffix(root.Path, "/") {
+		root.Path += "/"
+		root.RawPath = ""
+	}
+	root.RawQuery, root.Fragment = "", ""
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	seen := map[string]bool{root.String(): true}
+	pending := []walkDir{{url: root}}
+	inflight := 0
+	results := make(chan walkListing)
+
+	// stop cancels outstanding requests and waits for their goroutines so
+	// none is left blocked on results.
+	stop := func(err error) error {
+		cancel()
+		for ; inflight > 0; inflight-- {
+			<-results
+		}
+		if errors.Is(err, fs.SkipAll) {
+			return nil
+		}
+		return err
+	}
+
+	for len(pending) > 0 || inflight > 0 {
+		for inflight < workers && len(pending) > 0 {
+			d := pending[0]
+			pending = pending[1:]
+			inflight++
+			go func() {
+				entries, err := h.ListCtx(ctx, d.url.String())
+				results <- walkListing{dir: d, entries: entries, err: err}
+			}()
+		}
+
+		var r walkListing
+		select {
+		case r = <-results:
+			inflight--
+		case <-ctx.Done():
+			return stop(ctx.Err())
+		}
+
+		if r.err != nil {
+			if r.dir.entry.Depth == 0 {
+				return stop(r.err)
+			}
+			if err := fn(r.dir.entry, r.err); err != nil && !errors.Is(err, fs.SkipDir) {
+				return stop(err)
+			}
+			continue
+		}
+
+		depth := r.dir.entry.Depth + 1
+		for _, fe := range r.entries {
+			u, ok := childURL(root, r.dir.url, fe)
+			if !ok || seen[u.String()] {
+				continue
+			}
+			seen[u.String()] = true
+
+			name := strings.TrimSuffix(fe.Name, "/")
+			if matchAny(opts.Exclude, name) {
+				continue
+			}
+			if !fe.IsDir && len(opts.Include) > 0 && !matchAny(opts.Include, name) {
+				continue
+			}
+
+			// Callers put files at Path below a local folder, so a name
+			// like "%2e%2e" must not lead out of it.
+			p := path.Clean(strings.TrimPrefix(u.Path, root.Path))
+			if !filepath.IsLocal(filepath.FromSlash(p)) {
+				continue
+			}
+			e := WalkEntry{
+				FileEntry: fe,
+				Path:      p,
+				Depth:     depth,
+			}
+			e.URL = u.String()
+			err := fn(e, nil)
+			if fe.IsDir && errors.Is(err, fs.SkipDir) {
+				continue
+			}
+			if err != nil {
+				return stop(err)
+			}
+			if fe.IsDir && (opts.MaxDepth == 0 || depth < opts.MaxDepth) {
+				pending = append(pending, walkDir{entry: e, url: u})
+			}
+		}
+	}
+	return nil
+}
+
+// childURL resolves a listed entry and reports whether it's a real child of
+// dir inside root: sort links, "Parent directory" and anything pointing
+// upwards or off-site are rejected.
+func childURL(root, dir *url.URL, fe domain.FileEntry) (*url.URL, bool) {
+	switch strings.ToLower(strings.TrimSpace(fe.Name)) {
+	case "parent directory", "parent directory/", "..", "../", ".", "./":
+		return nil, false
+	}
+	u, err := url.Parse(fe.URL)
+	if err != nil || u.RawQuery != "" {
+		return nil, false
+	}
+	u.Fragment = ""
+	if u.Scheme != root.Scheme || u.Host != root.Host {
+		return nil, false
+	}
+	if !strings.HasPrefix(u.Path, dir.Path) || len(u.Path) <= len(dir.Path) {
+		return nil, false
+	}
+	return u, true
+}
+
+// matchAny reports whether name matches one of the glob patterns, ignoring
+// case.
+func matchAny(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether a walk with these options would report the file at
+// p, a slash-separated path below the root. It lets callers tell a file that
+// vanished upstream from one that was merely filtered out.
+func (o WalkOptions) Covers(p string) bool {
+	segs := strings.Split(p, "/")
+	if o.MaxDepth > 0 && len(segs) > o.MaxDepth {
+		return false
+	}
+	for _, s := range segs {
+		if matchAny(o.Exclude, s) {
+			return false
+		}
+	}
+	return len(o.Include) == 0 || matchAny(o.Include, segs[len(segs)-1])
+}
@@ -0,0 +1,90 @@
+// internal/selection/resolve.go
+package selection
+
+import (
+	"net/url"
+
+	"awesomeProject1/internal/domain"
+)
+
+// Result says which entries of a list were found in a listing.
+type Result struct {
+	// Found are the listed files the entries refer to, in list order.
+	Found []domain.FileEntry
+
+	// Missing are entries with no file in the listing: removed or renamed
+	// upstream, or from a folder that isn't loaded.
+	Missing []Entry
+}
+
+// Resolve looks up the list's entries among files. An entry matches the
+// file with the same URL; failing that, the one with the same name, which
+// lets lists made on another mirror resolve. When several files share the
+// name, the one whose systemOf equals the entry's System wins; if that
+// doesn't settle it, the entry counts as missing rather than guessing.
+// systemOf may be nil.
+func Resolve(l *List, files []domain.FileEntry, systemOf func(domain.FileEntry) string) Result {
+	byURL := make(map[string]int, len(files))
+	byName := make(map[string][]int, len(files))
+	for i, f := range files {
+		if f.IsDir {
+			continue
+		}
+		byURL[normalizeURL(f.URL)] = i
+		byName[f.Name] = append(byName[f.Name], i)
+	}
+
+	var res Result
+	seen := map[int]bool{}
+	for _, e := range l.Entries {
+		i, ok := -1, false
+		if e.URL != "" {
+			i, ok = byURL[normalizeURL(e.URL)]
+		}
+		if !ok {
+			i, ok = pickByName(e, byName[e.Name], files, systemOf)
+		}
+		if !ok {
+			res.Missing = append(res.Missing, e)
+			continue
+		}
+		if !seen[i] {
+			seen[i] = true
+			res.Found = append(res.Found, files[i])
+		}
+	}
+	return res
+}
+
+func pickByName(e Entry, candidates []int, files []domain.FileEntry, systemOf func(domain.FileEntry) string) (int, bool) {
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], true
+	case len(candidates) == 0 || e.System == "" || systemOf == nil:
+		return -1, false
+	}
+	match := -1
+	for _, i := range candidates {
+		if systemOf(files[i]) != e.System {
+			continue
+		}
+		if match >= 0 {
+			return -1, false
+		}
+		match = i
+	}
+	return match, match >= 0
+}
+
+// normalizeURL makes differently escaped spellings of the same URL equal.
+func normalizeURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	if p, err := url.PathUnescape(u.EscapedPath()); err == nil {
+		u.Path, u.RawPath = p, ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,136 @@
+// internal/selection/selection.go
+package selection
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Entry is one file in a saved selection. Only URL or Name is needed to
+// find it again; Size and System are there for people reading the list.
+type Entry struct {
+	URL    string `json:"url,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	System string `json:"system,omitempty"`
+}
+
+// List is a set of files picked from an index, saved so it can be loaded
+// again later or by someone else.
+type List struct {
+	// Name describes the list, e.g. "All USA NES games".
+	Name string `json:"name,omitempty"`
+
+	// Source is the index URL the files were picked from.
+	Source string `json:"source,omitempty"`
+
+	Created time.Time `json:"created"`
+	Entries []Entry   `json:"entries"`
+}
+
+// WriteJSON writes the list as indented JSON.
+func (l *List) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+// WriteText writes one URL per line, with the name and source as comments
+// at the top. Entries without a URL are written by name.
+func (l *List) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if l.Name != "" {
+		fmt.Fprintf(bw, "# %s\n", l.Name)
+	}
+	if l.Source != "" {
+		fmt.Fprintf(bw, "# source: %s\n", l.Source)
+	}
+	for _, e := range l.Entries {
+		if e.URL != "" {
+			fmt.Fprintln(bw, e.URL)
+		} else {
+			fmt.Fprintln(bw, e.Name)
+		}
+	}
+	return bw.Flush()
+}
+
+// Read parses a list written by WriteJSON or WriteText. Text lists may also
+// be hand-written: one URL or file name per line, blank lines and lines
+// starting with # ignored.
+func Read(r io.Reader) (*List, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '{' {
+		var l List
+		if err := json.Unmarshal(t, &l); err != nil {
+			return nil, fmt.Errorf("selection list: %w", err)
+		}
+		for i := range l.Entries {
+			l.Entries[i].fill()
+		}
+		return &l, nil
+	}
+	return readText(b)
+}
+
+func readText(b []byte) (*List, error) {
+	l := &List{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	first := true
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if c, ok := strings.CutPrefix(line, "#"); ok {
+			c = strings.TrimSpace(c)
+			if src, ok := strings.CutPrefix(c, "source:"); ok {
+				l.Source = strings.TrimSpace(src)
+			} else if first && l.Name == "" {
+				l.Name = c
+			}
+			continue
+		}
+		first = false
+
+		var e Entry
+		if u, err := url.Parse(line); err == nil && u.IsAbs() {
+			e.URL = line
+		} else {
+			e.Name = line
+		}
+		e.fill()
+		l.Entries = append(l.Entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(l.Entries) == 0 {
+		return nil, errors.New("selection list has no entries")
+	}
+	return l, nil
+}
+
+// fill derives a missing Name from the URL, so lists made from another
+// mirror can still be matched by file name.
+func (e *Entry) fill() {
+	if e.Name != "" || e.URL == "" {
+		return
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return
+	}
+	e.Name = path.Base(u.Path)
+}
@@ -0,0 +1,183 @@
+// internal/settings/settings.go
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/sysmap"
+	"awesomeProject1/internal/util"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Folder layouts: where a downloaded file goes below the download folder.
+const (
+	// LayoutSystem sorts files into a folder per system: the folder the
+	// first matching rule names, else the profile's folder for the system,
+	// else the first path segment below the root URL.
+	LayoutSystem = "system"
+
+	// LayoutFlat puts every file straight into the download folder.
+	LayoutFlat = "flat"
+)
+
+// Limits on the numeric settings, matching the GUI's sliders.
+const (
+	MaxConcurrency = 100
+	MaxConnections = 8
+)
+
+// Settings are the preferences the GUI, the command line and the daemon
+// share. The GUI keeps them in Fyne's preferences store; the command line
+// and daemon read them from a TOML file, where they become flag defaults.
+type Settings struct {
+	// DownloadDir is the folder downloads are saved under.
+	DownloadDir string `toml:"download_dir"`
+
+	// Concurrency is how many files download at once.
+	Concurrency int `toml:"concurrency"`
+
+	// Connections is how many ranges a large file is fetched in at once.
+	Connections int `toml:"connections"`
+
+	// Retries is the number of attempts per file; 0 uses the default
+	// retry policy.
+	Retries int `toml:"retries"`
+
+	// RateLimit caps the combined download rate, e.g. "500K" or "2M" per
+	// second; empty means unlimited.
+	RateLimit string `toml:"rate_limit"`
+
+	// Extract unpacks downloaded .zip archives and deletes them.
+	Extract bool `toml:"extract"`
+
+	// Layout is LayoutSystem or LayoutFlat.
+	Layout string `toml:"layout"`
+
+	// RootURL is the index URL system folders are named relative to. Empty
+	// means the URL loaded in the GUI; the command line then only sorts
+	// files the rules match.
+	RootURL string `toml:"root_url"`
+
+	// Rules map file paths to folders, ahead of Profile and the guess from
+	// RootURL.
+	Rules []sysmap.Rule `toml:"rules"`
+
+	// Profile is a built-in layout for a frontend, e.g. "mister": folder
+	// names, and which systems' archives are unpacked regardless of
+	// Extract. Empty for none.
+	Profile string `toml:"profile"`
+
+	// Mirrors are base URLs serving the same tree, preferred first.
+	Mirrors []string `toml:"mirrors"`
+
+	// LastURL is the index URL the GUI showed last.
+	LastURL string `toml:"last_url"`
+}
+
+// Defaults returns the settings used for anything not configured.
+func Defaults() Settings {
+	return Settings{
+		Concurrency: 4,
+		Connections: 1,
+		Extract:     true,
+		Layout:      LayoutSystem,
+	}
+}
+
+// Validate reports the first setting that is out of range.
+func (s Settings) Validate() error {
+	switch {
+	case s.Concurrency < 1 || s.Concurrency > MaxConcurrency:
+		return fmt.Errorf("concurrency must be 1–%d, not %d", MaxConcurrency, s.Concurrency)
+	case s.Connections < 1 || s.Connections > MaxConnections:
+		return fmt.Errorf("connections must be 1–%d, not %d", MaxConnections, s.Connections)
+	case s.Retries < 0:
+		return fmt.Errorf("retries must not be negative, not %d", s.Retries)
+	case s.Layout != LayoutSystem && s.Layout != LayoutFlat:
+		return fmt.Errorf("layout must be %q or %q, not %q", LayoutSystem, LayoutFlat, s.Layout)
+	}
+	if _, err := s.RateLimitBytes(); err != nil {
+		return err
+	}
+	if _, err := sysmap.New(s.Rules, s.Profile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RateLimitBytes returns RateLimit in bytes per second, 0 if unlimited.
+func (s Settings) RateLimitBytes() (int64, error) {
+	text := strings.TrimSpace(s.RateLimit)
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "/s"), "/S")
+	if text == "" {
+		return 0, nil
+	}
+	bps, ok := util.ParseSize(text)
+	if !ok {
+		return 0, fmt.Errorf("rate_limit %q is not a rate like 500K or 2M", s.RateLimit)
+	}
+	return bps, nil
+}
+
+// DefaultPath is the settings file: $MYRIENT_CONFIG if set, otherwise
+// config.toml in the app's folder under the user config dir, next to the
+// job history.
+func DefaultPath() (string, error) {
+	if p := os.Getenv("MYRIENT_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("user config dir: %w", err)
+	}
+	return filepath.Join(dir, "myrient-downloader", "config.toml"), nil
+}
+
+// Load reads the settings file at path. Settings it leaves out keep their
+// defaults, and a missing file is all defaults. Unknown keys are an error,
+// as they're most likely typos.
+func Load(path string) (Settings, error) {
+	s := Defaults()
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	md, err := toml.Decode(string(b), &s)
+	if err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return s, fmt.Errorf("%s: unknown setting %q", path, undecoded[0].String())
+	}
+	if err := s.Validate(); err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes s to path atomically, creating its folder if needed.
+func Save(path string, s Settings) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Myrient Download Manager settings. Command-line flags override these.\n\n")
+	if err := toml.NewEncoder(&buf).Encode(s); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
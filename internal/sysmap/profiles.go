@@ -0,0 +1,216 @@
+// internal/sysmap/profiles.go
+package sysmap
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Profile lays files out the way a frontend expects: the folder each system
+// goes in, and whether its archives are unpacked.
+type Profile struct {
+	// ID is the profile's name in the settings, e.g. "mister".
+	ID string
+
+	// Name is what the GUI shows.
+	Name string
+
+	// folders maps system IDs to folders; systems left out, which the
+	// frontend doesn't run, keep their ID.
+	folders map[string]string
+
+	// unzipAll unpacks every system's archives, for frontends that can't
+	// read them at all.
+	unzipAll bool
+}
+
+// Folder is where the profile puts s's files, slash separated.
+func (p *Profile) Folder(s System) string {
+	if f, ok := p.folders[s.ID]; ok {
+		return f
+	}
+	return s.ID
+}
+
+// Unzip reports whether the profile unpacks s's archives.
+func (p *Profile) Unzip(s System) bool {
+	return p.unzipAll || s.Unzip
+}
+
+// Profiles are the built-in layouts.
+var Profiles = []*Profile{
+	{
+		// RetroArch doesn't mind where ROMs are; its database names make
+		// scanned playlists line up with the folders.
+		ID:   "retroarch",
+		Name: "RetroArch",
+		folders: func() map[string]string {
+			m := map[string]string{"pcenginecd": "NEC - PC Engine CD - TurboGrafx-CD"}
+			for _, s := range Systems {
+				if _, ok := m[s.ID]; !ok {
+					m[s.ID] = s.Names[0]
+				}
+			}
+			return m
+		}(),
+	},
+	{
+		// The system IDs are ES-DE's folder names already.
+		ID:      "es-de",
+		Name:    "EmulationStation (ES-DE)",
+		folders: map[string]string{"3ds": "n3ds"},
+	},
+	{
+		ID:   "batocera",
+		Name: "Batocera",
+		folders: map[string]string{
+			"gc":              "gamecube",
+			"genesis":         "megadrive",
+			"sg-1000":         "sg1000",
+			"atarilynx":       "lynx",
+			"atarijaguar":     "jaguar",
+			"wonderswan":      "wswan",
+			"wonderswancolor": "wswanc",
+			"msx":             "msx1",
+			"odyssey2":        "o2em",
+		},
+	},
+	{
+		// Folders below games/; some cores run several systems.
+		ID:   "mister",
+		Name: "MiSTer",
+		folders: map[string]string{
+			"nes":             "NES",
+			"fds":             "NES",
+			"snes":            "SNES",
+			"n64":             "N64",
+			"gb":              "GAMEBOY",
+			"gbc":             "GAMEBOY",
+			"gba":             "GBA",
+			"genesis":         "Genesis",
+			"mastersystem":    "SMS",
+			"gamegear":        "SMS",
+			"segacd":          "MegaCD",
+			"sega32x":         "S32X",
+			"saturn":          "Saturn",
+			"sg-1000":         "Coleco",
+			"colecovision":    "Coleco",
+			"psx":             "PSX",
+			"pcengine":        "TGFX16",
+			"supergrafx":      "TGFX16",
+			"pcenginecd":      "TGFX16-CD",
+			"atari2600":       "ATARI2600",
+			"atari5200":       "ATARI5200",
+			"atari7800":       "ATARI7800",
+			"atarilynx":       "AtariLynx",
+			"atarijaguar":     "Jaguar",
+			"ngp":             "NGP",
+			"ngpc":            "NGP",
+			"wonderswan":      "WonderSwan",
+			"wonderswancolor": "WonderSwan",
+			"intellivision":   "Intellivision",
+			"msx":             "MSX",
+			"msx2":            "MSX",
+			"vectrex":         "VECTREX",
+			"odyssey2":        "ODYSSEY2",
+			"channelf":        "ChannelF",
+			"pokemini":        "PokemonMini",
+		},
+	},
+	{
+		// openFPGA cores read loose files from Assets/<platform>/common.
+		ID:   "pocket",
+		Name: "Analogue Pocket",
+		folders: pocketAssets(map[string]string{
+			"nes":             "nes",
+			"snes":            "snes",
+			"gb":              "gb",
+			"gbc":             "gbc",
+			"gba":             "gba",
+			"genesis":         "genesis",
+			"mastersystem":    "sms",
+			"gamegear":        "gg",
+			"sg-1000":         "sg1000",
+			"pcengine":        "pce",
+			"supergrafx":      "pce",
+			"pcenginecd":      "pcecd",
+			"atari2600":       "2600",
+			"atari7800":       "7800",
+			"atarilynx":       "lynx",
+			"ngp":             "ngp",
+			"ngpc":            "ngpc",
+			"wonderswan":      "wonderswan",
+			"wonderswancolor": "wonderswan",
+			"colecovision":    "coleco",
+			"intellivision":   "intv",
+			"odyssey2":        "odyssey2",
+			"channelf":        "channel_f",
+			"pokemini":        "poke_mini",
+		}),
+		unzipAll: true,
+	},
+}
+
+func pocketAssets(platforms map[string]string) map[string]string {
+	m := make(map[string]string, len(platforms))
+	for id, platform := range platforms {
+		m[id] = "Assets/" + platform + "/common"
+	}
+	return m
+}
+
+// ProfileByID returns the built-in profile called id, ignoring case.
+func ProfileByID(id string) (*Profile, bool) {
+	for _, p := range Profiles {
+		if strings.EqualFold(p.ID, id) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ProfileIDs lists the profiles' IDs, for messages and flag help.
+func ProfileIDs() []string {
+	ids := make([]string, len(Profiles))
+	for i, p := range Profiles {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+var systemFolderNames = func() []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(Systems))
+	for i, s := range Systems {
+		names := make([]string, len(s.Names))
+		for j, n := range s.Names {
+			names[j] = regexp.QuoteMeta(n)
+		}
+		res[i] = regexp.MustCompile(`(?i)^(` + strings.Join(names, "|") + `)( [-(].*)?$`)
+	}
+	return res
+}()
+
+// DetectFolder returns the systems a library folder, slash separated and
+// relative to the download folder, may hold. It knows system IDs, Myrient's
+// names as the last path segment, and every profile's folders. A folder
+// several systems share, like MiSTer's GAMEBOY, yields all of them; one
+// named after an ID or a Myrient name yields just that system.
+func DetectFolder(rel string) []System {
+	base := path.Base(rel)
+	for i, s := range Systems {
+		if strings.EqualFold(rel, s.ID) || systemFolderNames[i].MatchString(base) {
+			return []System{s}
+		}
+	}
+	var found []System
+	for _, s := range Systems {
+		for _, p := range Profiles {
+			if strings.EqualFold(rel, p.Folder(s)) {
+				found = append(found, s)
+				break
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,166 @@
+// internal/sysmap/sysmap.go
+package sysmap
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"awesomeProject1/internal/util"
+)
+
+// Rule sends files whose path matches Pattern to Folder.
+type Rule struct {
+	// Pattern is a regular expression matched against the file's path below
+	// the root URL, unescaped and without a leading slash, e.g.
+	// "No-Intro/Nintendo - Super Nintendo Entertainment System/Game.zip".
+	// Files outside the root are matched by their whole URL path.
+	Pattern string `toml:"pattern" json:"pattern"`
+
+	// Folder is where matching files go below the download folder. It may
+	// refer to the pattern's groups as $1 or ${name}, and may contain "/"
+	// for nested folders. Empty leaves matching files unsorted.
+	Folder string `toml:"folder" json:"folder"`
+}
+
+// Mapper picks a file's folder by the first rule that matches it, then by
+// the profile's folder for its system, falling back to the system guessed
+// from the URL. A nil *Mapper has no rules and no profile.
+type Mapper struct {
+	rules   []Rule
+	res     []*regexp.Regexp
+	profile *Profile
+}
+
+// Results of Match when no rule decided.
+const (
+	// Guessed is a folder named after the first path segment below the
+	// root, or none.
+	Guessed = -1
+
+	// Profiled is the profile's folder for the file's system.
+	Profiled = -2
+)
+
+// New compiles rules, reporting the first one that isn't valid. profile is
+// the ID of a built-in profile, or "" for none.
+func New(rules []Rule, profile string) (*Mapper, error) {
+	m := &Mapper{rules: rules, res: make([]*regexp.Regexp, len(rules))}
+	if profile != "" {
+		p, ok := ProfileByID(profile)
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q (want one of %s)", profile, strings.Join(ProfileIDs(), ", "))
+		}
+		m.profile = p
+	}
+	for i, r := range rules {
+		if strings.TrimSpace(r.Pattern) == "" {
+			return nil, fmt.Errorf("folder rule %d has no pattern", i+1)
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("folder rule %d: %w", i+1, err)
+		}
+		m.res[i] = re
+	}
+	return m, nil
+}
+
+// Profile returns m's profile, nil if it has none.
+func (m *Mapper) Profile() *Profile {
+	if m == nil {
+		return nil
+	}
+	return m.profile
+}
+
+// Folder returns the folder, slash separated, that the file at fileURL is
+// sorted into, or "" to leave it in the download folder.
+func (m *Mapper) Folder(root, fileURL string) string {
+	folder, _ := m.Match(root, fileURL)
+	return folder
+}
+
+// Match is Folder, also returning the index of the rule that decided it,
+// or Profiled or Guessed. Without a root there is no guess.
+func (m *Mapper) Match(root, fileURL string) (folder string, rule int) {
+	if m != nil {
+		p := RelPath(root, fileURL)
+		for i, re := range m.res {
+			match := re.FindStringSubmatchIndex(p)
+			if match == nil {
+				continue
+			}
+			folder := string(re.ExpandString(nil, m.rules[i].Folder, p, match))
+			return cleanFolder(folder), i
+		}
+		if m.profile != nil {
+			if s, ok := Detect(fileURL); ok {
+				return m.profile.Folder(s), Profiled
+			}
+		}
+	}
+	if root == "" {
+		return "", Guessed
+	}
+	if system := util.GuessSystemFromURL(root, fileURL); system != "Unknown" {
+		return system, Guessed
+	}
+	return "", Guessed
+}
+
+// Unzip reports whether m's profile unpacks the archive at fileURL; ok is
+// false without a profile or when the system isn't known. It fits
+// download.ExtractRule.
+func (m *Mapper) Unzip(fileURL string) (unzip, ok bool) {
+	if m == nil || m.profile == nil {
+		return false, false
+	}
+	s, ok := Detect(fileURL)
+	if !ok {
+		return false, false
+	}
+	return m.profile.Unzip(s), true
+}
+
+// RelPath is the unescaped path of fileURL below root, or its whole path if
+// it isn't under root; either way without a leading slash.
+func RelPath(root, fileURL string) string {
+	p := unescapedPath(fileURL)
+	if root != "" {
+		rp := strings.TrimRight(unescapedPath(root), "/")
+		if rel, ok := strings.CutPrefix(p, rp+"/"); ok {
+			return rel
+		}
+	}
+	return strings.TrimLeft(p, "/")
+}
+
+// Under reports whether fileURL's path is below root's. Hosts aren't
+// compared, so files from a mirror of root count.
+func Under(root, fileURL string) bool {
+	return root != "" && strings.HasPrefix(unescapedPath(fileURL), strings.TrimRight(unescapedPath(root), "/")+"/")
+}
+
+func unescapedPath(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Path
+}
+
+// cleanFolder makes each segment of folder safe as a folder name and drops
+// empty, "." and ".." segments, so a rule can't leave the download folder.
+func cleanFolder(folder string) string {
+	var parts []string
+	for _, seg := range strings.Split(folder, "/") {
+		seg = strings.TrimSpace(seg)
+		if seg == "" || seg == "." || seg == ".." {
+			continue
+		}
+		parts = append(parts, util.SanitizeFolderName(seg))
+	}
+	return strings.Join(parts, "/")
+}
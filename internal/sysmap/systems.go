@@ -0,0 +1,140 @@
+// internal/sysmap/systems.go
+package sysmap
+
+import (
+	"regexp"
+	"strings"
+)
+
+// System is a platform as Myrient's collections name it.
+type System struct {
+	// ID is a short lowercase name, as frontends like EmulationStation use
+	// for their folders, e.g. "snes".
+	ID string
+
+	// Names are the folder names the No-Intro and Redump collections use
+	// for the system. Either may add a suffix like " (Headered)" or
+	// " - NKit RVZ [zstd-19-128k]".
+	Names []string
+
+	// Unzip is set for systems emulators want unpacked: disc images, and
+	// 3DS titles. Cartridge ROMs load fine from a .zip.
+	Unzip bool
+}
+
+// Systems are the platforms the built-in presets know, in no particular
+// order; their patterns don't overlap.
+var Systems = []System{
+	{"nes", []string{"Nintendo - Nintendo Entertainment System"}, false},
+	{"fds", []string{"Nintendo - Family Computer Disk System"}, false},
+	{"snes", []string{"Nintendo - Super Nintendo Entertainment System"}, false},
+	{"n64", []string{"Nintendo - Nintendo 64"}, false},
+	{"n64dd", []string{"Nintendo - Nintendo 64DD"}, false},
+	{"gb", []string{"Nintendo - Game Boy"}, false},
+	{"gbc", []string{"Nintendo - Game Boy Color"}, false},
+	{"gba", []string{"Nintendo - Game Boy Advance"}, false},
+	{"nds", []string{"Nintendo - Nintendo DS"}, false},
+	{"3ds", []string{"Nintendo - Nintendo 3DS"}, true},
+	{"gc", []string{"Nintendo - GameCube"}, true},
+	{"wii", []string{"Nintendo - Wii"}, true},
+	{"wiiu", []string{"Nintendo - Wii U"}, true},
+	{"virtualboy", []string{"Nintendo - Virtual Boy"}, false},
+	{"pokemini", []string{"Nintendo - Pokemon Mini"}, false},
+	{"genesis", []string{"Sega - Mega Drive - Genesis"}, false},
+	{"mastersystem", []string{"Sega - Master System - Mark III"}, false},
+	{"gamegear", []string{"Sega - Game Gear"}, false},
+	{"segacd", []string{"Sega - Mega-CD - Sega CD"}, true},
+	{"sega32x", []string{"Sega - 32X"}, false},
+	{"saturn", []string{"Sega - Saturn"}, true},
+	{"dreamcast", []string{"Sega - Dreamcast"}, true},
+	{"sg-1000", []string{"Sega - SG-1000"}, false},
+	{"psx", []string{"Sony - PlayStation"}, true},
+	{"ps2", []string{"Sony - PlayStation 2"}, true},
+	{"ps3", []string{"Sony - PlayStation 3"}, true},
+	{"psp", []string{"Sony - PlayStation Portable"}, true},
+	{"psvita", []string{"Sony - PlayStation Vita"}, true},
+	{"pcengine", []string{"NEC - PC Engine - TurboGrafx-16"}, false},
+	{"pcenginecd", []string{"NEC - PC Engine CD & TurboGrafx CD"}, true},
+	{"supergrafx", []string{"NEC - PC Engine SuperGrafx"}, false},
+	{"atari2600", []string{"Atari - 2600"}, false},
+	{"atari5200", []string{"Atari - 5200"}, false},
+	{"atari7800", []string{"Atari - 7800"}, false},
+	{"atarilynx", []string{"Atari - Lynx"}, false},
+	{"atarijaguar", []string{"Atari - Jaguar"}, false},
+	{"ngp", []string{"SNK - Neo Geo Pocket"}, false},
+	{"ngpc", []string{"SNK - Neo Geo Pocket Color"}, false},
+	{"wonderswan", []string{"Bandai - WonderSwan"}, false},
+	{"wonderswancolor", []string{"Bandai - WonderSwan Color"}, false},
+	{"colecovision", []string{"Coleco - ColecoVision"}, false},
+	{"intellivision", []string{"Mattel - Intellivision"}, false},
+	{"msx", []string{"Microsoft - MSX"}, false},
+	{"msx2", []string{"Microsoft - MSX2"}, false},
+	{"3do", []string{"The 3DO Company - 3DO", "Panasonic - 3DO Interactive Multiplayer"}, true},
+	{"vectrex", []string{"GCE - Vectrex"}, false},
+	{"odyssey2", []string{"Magnavox - Odyssey 2"}, false},
+	{"channelf", []string{"Fairchild - Channel F"}, false},
+}
+
+// Pattern matches paths with a folder named after s anywhere in them. The
+// name must make up the whole folder name, give or take a suffix starting
+// " (" or " -", so "Nintendo - Game Boy" doesn't match the Game Boy Color
+// folder.
+func (s System) Pattern() string {
+	names := make([]string, len(s.Names))
+	for i, n := range s.Names {
+		names[i] = regexp.QuoteMeta(n)
+	}
+	return `(^|/)(` + strings.Join(names, "|") + `)( [-(][^/]*)?/`
+}
+
+// Rule sends the system's files to folder.
+func (s System) Rule(folder string) Rule {
+	return Rule{Pattern: s.Pattern(), Folder: folder}
+}
+
+// Preset is a built-in set of rules.
+type Preset struct {
+	Name  string
+	Rules []Rule
+}
+
+// Presets returns the built-in rule sets:
+//   - "Myrient folders" has no rules, so files go in the folder named after
+//     the first path segment below the root, as Myrient names it.
+//   - "System names" names the folder after the system wherever it is in
+//     the path, so collection folders like "No-Intro" don't matter.
+//   - "Short names" does the same with short names like "snes".
+func Presets() []Preset {
+	names := make([]Rule, len(Systems))
+	ids := make([]Rule, len(Systems))
+	for i, s := range Systems {
+		names[i] = s.Rule(s.Names[0])
+		ids[i] = s.Rule(s.ID)
+	}
+	return []Preset{
+		{Name: "Myrient folders"},
+		{Name: "System names", Rules: names},
+		{Name: "Short names", Rules: ids},
+	}
+}
+
+var systemPatterns = func() []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(Systems))
+	for i, s := range Systems {
+		res[i] = regexp.MustCompile(s.Pattern())
+	}
+	return res
+}()
+
+// Detect returns the system whose folder appears in fileURL's path. Unlike
+// util.GuessSystemFromURL it doesn't need the folder to be the first one
+// below a root, so collection folders like "No-Intro" don't get in the way.
+func Detect(fileURL string) (System, bool) {
+	p := RelPath("", fileURL)
+	for i, re := range systemPatterns {
+		if re.MatchString(p) {
+			return Systems[i], true
+		}
+	}
+	return System{}, false
+}
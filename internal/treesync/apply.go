@@ -0,0 +1,221 @@
+// internal/treesync/apply.go
+package treesync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/util"
+)
+
+// Options control what a sync does to local files.
+type Options struct {
+	// Delete removes local files that vanished upstream.
+	Delete bool
+
+	// Quarantine, if set, is a folder that local files which vanished
+	// upstream, and the old copies of updated ones, are moved into, keeping
+	// their path below the root. It takes precedence over Delete.
+	Quarantine string
+}
+
+// oldSuffix marks the old copy of an updated file while the new one is
+// downloaded.
+const oldSuffix = ".myrient-old"
+
+// Prepare makes room for the downloads and handles removals: the old copies
+// of updated files are set aside, since the downloader skips files that
+// exist, and files gone upstream are quarantined or deleted if opts say so.
+// Items that couldn't be prepared get Err set and are left out of Downloads.
+func (p *Plan) Prepare(opts Options) {
+	p.opts = opts
+	for i := range p.Items {
+		it := &p.Items[i]
+		switch it.Action {
+		case Update:
+			local := p.LocalPath(it.Path)
+			if _, err := os.Stat(local); err != nil {
+				continue // unpacked archive or already gone
+			}
+			// Finish drops it once the new copy is in, or puts it back.
+			it.Err = os.Rename(local, local+oldSuffix)
+		case Remove:
+			switch {
+			case opts.Quarantine == "" && !opts.Delete:
+				it.Reason = "kept locally"
+				continue
+			case p.manifest.Files[it.Path].Extracted:
+				it.Reason = "was unpacked, left in place"
+			default:
+				if err := p.discard(p.LocalPath(it.Path), it.Path, opts); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					it.Err = err
+					continue
+				}
+				it.Reason = "deleted"
+				if opts.Quarantine != "" {
+					it.Reason = "quarantined"
+				}
+			}
+			delete(p.manifest.Files, it.Path)
+		}
+	}
+}
+
+// discard moves src, the local copy of path, into quarantine, or deletes it.
+func (p *Plan) discard(src, path string, opts Options) error {
+	if opts.Quarantine == "" {
+		return os.Remove(src)
+	}
+	return moveFile(src, filepath.Join(opts.Quarantine, filepath.FromSlash(path)))
+}
+
+// Finish records the outcome of the downloads: failed reports the error a
+// download ended with, nil if it succeeded. Downloaded files get their listed
+// date as modification time, and the old copies of updated ones are
+// quarantined or deleted; those whose download failed are put back. The
+// manifest is saved and the summary appended to the log in the local root.
+func (p *Plan) Finish(failed func(path string) error) error {
+	for i := range p.Items {
+		it := &p.Items[i]
+		switch it.Action {
+		case Add, Update:
+			if it.Err == nil {
+				it.Err = failed(it.Path)
+			}
+			if it.Action == Update {
+				p.settleOld(it)
+			}
+			if it.Err != nil {
+				continue
+			}
+			rec := record{Size: it.Size, Modified: it.Modified}
+			local := p.LocalPath(it.Path)
+			if _, err := os.Stat(local); errors.Is(err, fs.ErrNotExist) && strings.HasSuffix(strings.ToLower(it.Path), ".zip") {
+				rec.Extracted = true
+			} else if !it.Modified.IsZero() {
+				os.Chtimes(local, it.Modified, it.Modified)
+			}
+			p.manifest.Files[it.Path] = rec
+		case Keep:
+			if _, ok := p.manifest.Files[it.Path]; !ok {
+				p.manifest.Files[it.Path] = record{Size: it.Size, Modified: it.Modified}
+			}
+		}
+	}
+	p.manifest.Remote = p.Remote
+
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		return err
+	}
+	if err := p.manifest.save(p.Dir); err != nil {
+		return fmt.Errorf("save %s: %w", ManifestName, err)
+	}
+	f, err := os.OpenFile(filepath.Join(p.Dir, LogName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteSummary(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// settleOld deals with the old copy Prepare set aside for it: dropped if
+// the update succeeded, restored if it didn't.
+func (p *Plan) settleOld(it *Item) {
+	local := p.LocalPath(it.Path)
+	old := local + oldSuffix
+	if _, err := os.Stat(old); err != nil {
+		return
+	}
+	if it.Err != nil {
+		if _, err := os.Stat(local); errors.Is(err, fs.ErrNotExist) {
+			os.Rename(old, local)
+		}
+		return
+	}
+	if err := p.discard(old, it.Path, p.opts); err != nil {
+		it.Reason += fmt.Sprintf("; old copy left at %s: %v", old, err)
+	}
+}
+
+// WriteSummary writes a line per added, updated, removed or failed item and
+// a closing count.
+func (p *Plan) WriteSummary(w io.Writer) error {
+	fmt.Fprintf(w, "== %s  %s -> %s\n", p.started.Format("2006-01-02 15:04:05"), p.Remote, p.Dir)
+	var added, updated, removed, failed int
+	for _, it := range p.Items {
+		if it.Action == Keep {
+			continue
+		}
+		if it.Err != nil {
+			failed++
+			fmt.Fprintf(w, "FAILED   %s %s: %v\n", it.Action, it.Path, it.Err)
+			continue
+		}
+		switch it.Action {
+		case Add:
+			added++
+			fmt.Fprintf(w, "added    %s%s\n", it.Path, sizeNote(it.Size))
+		case Update:
+			updated++
+			fmt.Fprintf(w, "updated  %s%s, %s\n", it.Path, sizeNote(it.Size), it.Reason)
+		case Remove:
+			removed++
+			fmt.Fprintf(w, "removed  %s (%s)\n", it.Path, it.Reason)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d updated, %d removed upstream, %d unchanged, %d failed\n\n",
+		added, updated, removed, p.Count(Keep), failed)
+	return err
+}
+
+func sizeNote(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	return " (" + util.FormatBytes(n, 1) + ")"
+}
+
+// moveFile renames src to dst, creating dst's folder, and falls back to
+// copying when they're on different file systems.
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	err := os.Rename(src, dst)
+	var le *os.LinkError
+	if err == nil || !errors.As(err, &le) {
+		return err
+	}
+	if _, serr := os.Stat(src); serr != nil {
+		return serr
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
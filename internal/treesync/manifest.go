@@ -0,0 +1,72 @@
+// internal/treesync/manifest.go
+package treesync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// ManifestName is the file in the local root that remembers what each
+	// synced file looked like upstream.
+	ManifestName = ".myrient-sync.json"
+
+	// LogName is the file in the local root each run's summary is appended to.
+	LogName = ".myrient-sync.log"
+)
+
+// record is what the listing said about a file when it was last synced.
+type record struct {
+	Size     int64     `json:"size,omitempty"`
+	Modified time.Time `json:"modified"`
+
+	// Extracted is set for archives that were unpacked and deleted after
+	// downloading, so their absence locally is expected.
+	Extracted bool `json:"extracted,omitempty"`
+}
+
+// manifest maps paths below the root, slash separated, to their records.
+type manifest struct {
+	Remote string            `json:"remote"`
+	Files  map[string]record `json:"files"`
+}
+
+// loadManifest reads the manifest in dir. A missing one is an empty manifest:
+// the first run against an existing folder.
+func loadManifest(dir string) (*manifest, error) {
+	m := &manifest{Files: map[string]record{}}
+	b, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestName, err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]record{}
+	}
+	return m, nil
+}
+
+// save writes the manifest atomically, so an interrupted run leaves the
+// previous one intact.
+func (m *manifest) save(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, ManifestName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,204 @@
+// internal/treesync/plan.go
+package treesync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"awesomeProject1/internal/scraper"
+)
+
+// mtimeSlack absorbs listings that only show minutes and servers that round.
+const mtimeSlack = time.Minute
+
+// Action is what a sync does with one file.
+type Action string
+
+const (
+	Add    Action = "add"    // new upstream or missing locally
+	Update Action = "update" // changed upstream since it was synced
+	Remove Action = "remove" // synced earlier but gone upstream
+	Keep   Action = "keep"   // unchanged
+)
+
+// Item is one file in a Plan.
+type Item struct {
+	Path     string // below the root, slash separated
+	URL      string // empty for Remove
+	Action   Action
+	Size     int64     // listed size, 0 if unknown
+	Modified time.Time // listed date, zero if unknown
+	Reason   string    // why it's added or updated, what became of a removal
+
+	// Err is why applying the item failed, nil if it hasn't or didn't.
+	Err error
+}
+
+// Plan is the difference between a remote tree and a local folder.
+type Plan struct {
+	Remote string
+	Dir    string
+	Items  []Item
+
+	// Partial is set when part of the remote tree couldn't be listed.
+	// Nothing is planned for removal then, since a missing listing looks
+	// exactly like deleted files.
+	Partial bool
+
+	started  time.Time
+	manifest *manifest
+	opts     Options // as given to Prepare
+}
+
+// List walks remote with opts and returns every file found. Directories
+// that fail to list are passed to onErr and make the result partial.
+func List(ctx context.Context, idx *scraper.HTTPIndex, remote string, opts scraper.WalkOptions, onErr func(path string, err error)) (files []scraper.WalkEntry, partial bool, err error) {
+	err = idx.Walk(ctx, remote, opts, func(e scraper.WalkEntry, err error) error {
+		if err != nil {
+			partial = true
+			if onErr != nil {
+				onErr(e.Path, err)
+			}
+			return nil
+		}
+		if !e.IsDir && filepath.IsLocal(filepath.FromSlash(e.Path)) {
+			files = append(files, e)
+		}
+		return nil
+	})
+	return files, partial, err
+}
+
+// Compare plans the sync of files, as listed by List with opts, into dir.
+//
+// A file synced before is compared with what the listing said back then, so
+// archives unpacked after downloading and sizes the listing rounds are no
+// problem. Any other local file is compared by size, allowing for rounding,
+// and by age: it's updated only if the listing says it changed after the
+// local copy was written. Only previously synced files are ever removed,
+// and only those opts would have listed.
+//
+// A folder synced from another remote is refused: every file of the earlier
+// sync would look gone upstream.
+func Compare(remote, dir string, files []scraper.WalkEntry, partial bool, opts scraper.WalkOptions) (*Plan, error) {
+	man, err := loadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if man.Remote != "" && strings.TrimSuffix(man.Remote, "/") != strings.TrimSuffix(remote, "/") {
+		return nil, fmt.Errorf("%s was synced from %s, not %s; sync it from there, or remove %s to start over",
+			dir, man.Remote, remote, filepath.Join(dir, ManifestName))
+	}
+	p := &Plan{
+		Remote:   remote,
+		Dir:      dir,
+		Partial:  partial,
+		started:  time.Now(),
+		manifest: man,
+	}
+
+	listed := make(map[string]bool, len(files))
+	for _, e := range files {
+		listed[e.Path] = true
+		it := Item{Path: e.Path, URL: e.URL, Size: e.Size, Modified: e.Modified}
+		rec, synced := man.Files[e.Path]
+		fi, err := os.Stat(p.LocalPath(e.Path))
+		have := err == nil && fi.Mode().IsRegular() && fi.Size() > 0
+
+		switch {
+		case synced && rec.Size > 0 && e.Size > 0 && rec.Size != e.Size:
+			it.Action, it.Reason = Update, "size changed"
+		case synced && !rec.Modified.IsZero() && !e.Modified.IsZero() && !rec.Modified.Equal(e.Modified):
+			it.Action, it.Reason = Update, "modified upstream"
+		case synced && (have || rec.Extracted):
+			it.Action = Keep
+		case synced:
+			it.Action, it.Reason = Add, "missing locally"
+		case !have:
+			it.Action, it.Reason = Add, "new"
+		case e.Size > 0 && !sizeMatches(fi.Size(), e.Size):
+			it.Action, it.Reason = Update, "size differs"
+		case !e.Modified.IsZero() && e.Modified.After(fi.ModTime().Add(mtimeSlack)):
+			it.Action, it.Reason = Update, "newer upstream"
+		default:
+			it.Action = Keep
+		}
+		p.Items = append(p.Items, it)
+	}
+
+	if !partial {
+		for path, rec := range man.Files {
+			if listed[path] || !opts.Covers(path) {
+				continue
+			}
+			p.Items = append(p.Items, Item{
+				Path:     path,
+				Action:   Remove,
+				Size:     rec.Size,
+				Modified: rec.Modified,
+				Reason:   "gone upstream",
+			})
+		}
+	}
+
+	sort.Slice(p.Items, func(i, j int) bool { return p.Items[i].Path < p.Items[j].Path })
+	return p, nil
+}
+
+// sizeMatches compares a local size with a listed one that may have been
+// rounded to a couple of digits, as in "1.2M".
+func sizeMatches(local, listed int64) bool {
+	diff := local - listed
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= listed/20 || diff < 1024
+}
+
+// LocalPath is where the file at path below the root lives locally.
+func (p *Plan) LocalPath(path string) string {
+	return filepath.Join(p.Dir, filepath.FromSlash(path))
+}
+
+// Filter returns the items with action a.
+func (p *Plan) Filter(a Action) []Item {
+	var out []Item
+	for _, it := range p.Items {
+		if it.Action == a {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Count returns how many items have action a.
+func (p *Plan) Count(a Action) int {
+	return len(p.Filter(a))
+}
+
+// Downloads returns the items that need downloading: additions and updates
+// that Prepare didn't fail on.
+func (p *Plan) Downloads() []Item {
+	var out []Item
+	for _, it := range p.Items {
+		if (it.Action == Add || it.Action == Update) && it.Err == nil {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// Summary is a one-line count of the plan.
+func (p *Plan) Summary() string {
+	s := fmt.Sprintf("%d to add, %d to update, %d removed upstream, %d unchanged",
+		p.Count(Add), p.Count(Update), p.Count(Remove), p.Count(Keep))
+	if p.Partial {
+		s += " (listing incomplete, nothing removed)"
+	}
+	return s
+}
@@ -2,20 +2,36 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"image/color"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"awesomeProject1/internal/audit"
+	"awesomeProject1/internal/dat"
 	"awesomeProject1/internal/domain"
 	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/jobstore"
+	"awesomeProject1/internal/mirror"
+	"awesomeProject1/internal/organize"
 	"awesomeProject1/internal/scraper"
+	"awesomeProject1/internal/selection"
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/sysmap"
 	"awesomeProject1/internal/util"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
 	//"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
 )
@@ -24,16 +40,29 @@ import (
 type selectableEntry struct {
 	Item     domain.FileEntry
 	Selected bool
+
+	// Path is the entry's path below the crawled folder; empty for a
+	// single-page listing.
+	Path string
+}
+
+// label is the text shown for the entry in the file list.
+func (e selectableEntry) label() string {
+	if e.Path != "" {
+		return e.Path
+	}
+	return e.Item.Name
 }
 
 // startTitleMarquee runs a simple "marquee" effect in the window title.
 // It rotates the given text forever until the app exits.
-func startTitleMarquee(w fyne.Window, text string) {
+func startTitleMarquee(w fyne.Window, text string, pump *uiPump) {
 	go func() {
 		marquee := text + "   "
 
 		for {
-			w.SetTitle(marquee)
+			title := marquee
+			pump.Throttle("title", func() { w.SetTitle(title) })
 
 			if len(marquee) > 0 {
 				marquee = marquee[1:] + marquee[:1]
@@ -58,19 +87,23 @@ func Run() {
 
 	w.Resize(fyne.NewSize(800, 550))
 
-	// Start scrolling the title bar text
-	startTitleMarquee(w, baseTitle)
-
 	httpIdx := scraper.NewHTTPIndex()
 
-	// first loaded URL becomes the "root" for system detection
-	rootURL := ""
+	// Settings persist in the app's preferences; changeConf stores them
+	// after every change. conf is how they were at launch.
+	prefs := a.Preferences()
+	state := newAppState(loadPreferences(prefs))
+	conf := state.Conf()
+	changeConf := func(f func(c *settings.Settings)) {
+		state.Update(f)
+		savePreferences(prefs, state.Conf())
+	}
 
 	// base local directory where downloads will be saved
-	baseDownloadDir := ""
+	baseDownloadDir := conf.DownloadDir
 
-	// default concurrency for bulk downloads
-	maxConcurrent := 4
+	// concurrency for bulk downloads
+	maxConcurrent := conf.Concurrency
 
 	// ---------- LOG CONSOLE ----------
 	logOutput := widget.NewMultiLineEntry()
@@ -81,10 +114,9 @@ func Run() {
 
 	const maxLogChars = 20000
 
-	appendLogUnsafe := func(msg string) {
-		// This function assumes it's already running on the UI thread.
-		line := msg + "\n"
-		newText := logOutput.Text + line
+	// appendLog adds lines to the log console. Only the UI pump calls it.
+	appendLog := func(lines []string) {
+		newText := logOutput.Text + strings.Join(lines, "\n") + "\n"
 		if len(newText) > maxLogChars {
 			// keep last maxLogChars characters, cut at a newline boundary if possible
 			newText = newText[len(newText)-maxLogChars:]
@@ -95,27 +127,67 @@ func Run() {
 		logOutput.SetText(newText)
 	}
 
-	console := download.NewConsole(func(msg string) {
-		// Simple, no driver tricks – if this ever misbehaves,
-		// we can later add a log channel + UI pump.
-		appendLogUnsafe(msg)
-	})
+	// Background work never touches widgets itself; it hands changes to
+	// the pump, which applies them on one goroutine and batches log lines.
+	pump := newUIPump(100*time.Millisecond, appendLog)
+
+	console := download.NewConsole(pump.Log)
+
+	// Start scrolling the title bar text
+	startTitleMarquee(w, baseTitle, pump)
 
 	dlMgr := download.NewManager(console)
+	dlMgr.SetExtract(conf.Extract)
+	dlMgr.SetExtractRule(state.Folders().Unzip)
+
+	// applyRetries sets the attempts per file; 0 restores the default.
+	applyRetries := func(n int) {
+		policy := dlMgr.RetryPolicy()
+		policy.MaxAttempts = util.DefaultRetryPolicy().MaxAttempts
+		if n > 0 {
+			policy.MaxAttempts = n
+		}
+		dlMgr.SetRetryPolicy(policy)
+	}
+	applyRetries(conf.Retries)
+
+	// Expected checksums from loaded sidecar files and the loaded DAT;
+	// downloads of files listed there are verified.
+	checksumIdx := download.NewChecksumIndex()
+	var datIdx *dat.Index
+	dlMgr.SetChecksumSource(checksumIdx)
+
+	// Mirrors of the same tree; listing and downloads fail over between them.
+	mirrors := mirror.New(conf.Mirrors)
+	httpIdx.SetMirrors(mirrors)
+	dlMgr.SetMirrors(mirrors)
 
 	// ---------- TOP BAR ----------
 	urlEntry := widget.NewEntry()
 	urlEntry.SetPlaceHolder("Enter index URL (e.g. https://myrient.erista.me/files/)")
+	urlEntry.SetText(conf.LastURL)
 	// Example default (SNES No-Intro):
 	// urlEntry.SetText("https://myrient.erista.me/files/No-Intro/Nintendo%20-%20Super%20Nintendo%20Entertainment%20System/")
 
 	loadBtn := widget.NewButton("Load", nil)
+	crawlBtn := widget.NewButton("Load recursively…", nil)
+
+	// Index page format; "Auto" detects it per page.
+	formatNames := []string{"Auto"}
+	for _, p := range scraper.Parsers {
+		formatNames = append(formatNames, p.Name())
+	}
+	formatSelect := widget.NewSelect(formatNames, func(name string) {
+		p, _ := scraper.ParserByName(name)
+		httpIdx.SetParser(p)
+	})
+	formatSelect.SetSelected("Auto")
 
 	header := container.NewBorder(
 		nil,
 		nil,
 		widget.NewLabel("  HTTP Index Browser"),
-		loadBtn,
+		container.NewHBox(formatSelect, loadBtn, crawlBtn),
 		urlEntry,
 	)
 
@@ -126,52 +198,52 @@ func Run() {
 
 	// ---------- LEFT: DIRECTORY LIST + SEARCH ----------
 
-	// allEntries = full set from current page
-	// filteredIdx = indexes into allEntries that currently match the search term
-	allEntries := []selectableEntry{}
-	filteredIdx := []int{}
-	selectedListIndex := -1 // index into filteredIdx
+	// entries = full set from the current page or crawl, plus the rows
+	// matching the search term
+	entries := newEntryList()
 
 	// label for number of selected files
 	selectedCountLabel := widget.NewLabel("Selected files: 0")
 
 	var updateSelectedCount func()
-	var applyFilter func(term string)
 
 	// Search bar
 	searchEntry := widget.NewEntry()
 	searchEntry.SetPlaceHolder("Search/filter files (e.g. 'Crash Bandicoot')")
 
 	list := widget.NewList(
-		func() int { return len(filteredIdx) },
+		entries.Len,
 		func() fyne.CanvasObject {
-			// row = checkbox + label
+			// row = checkbox + name + size/date
+			detail := widget.NewLabel("")
+			detail.Importance = widget.LowImportance
 			return container.NewHBox(
 				widget.NewCheck("", nil),
 				widget.NewLabel(""),
+				layout.NewSpacer(),
+				detail,
 			)
 		},
 		func(i widget.ListItemID, o fyne.CanvasObject) {
-			if i < 0 || i >= len(filteredIdx) {
+			e, ok := entries.Row(i)
+			if !ok {
 				return
 			}
 			row := o.(*fyne.Container)
 			chk := row.Objects[0].(*widget.Check)
 			lbl := row.Objects[1].(*widget.Label)
-
-			globalIdx := filteredIdx[i]
-			e := &allEntries[globalIdx]
+			detail := row.Objects[3].(*widget.Label)
 
 			// Avoid firing OnChanged while we sync state
 			chk.OnChanged = nil
 
-			lbl.SetText(e.Item.Name)
+			lbl.SetText(e.label())
+			detail.SetText(entryDetails(e.Item))
 			chk.SetChecked(e.Selected)
 
 			iCopy := i
 			chk.OnChanged = func(b bool) {
-				globalIdx := filteredIdx[iCopy]
-				allEntries[globalIdx].Selected = b
+				entries.Check(iCopy, b)
 				if updateSelectedCount != nil {
 					updateSelectedCount()
 				}
@@ -181,105 +253,455 @@ func Run() {
 
 	// Track which item is selected (for single-file actions)
 	list.OnSelected = func(id widget.ListItemID) {
-		selectedListIndex = int(id)
+		entries.Highlight(int(id))
 	}
 	list.OnUnselected = func(id widget.ListItemID) {
-		if selectedListIndex == int(id) {
-			selectedListIndex = -1
-		}
+		entries.Unhighlight(int(id))
 	}
 
-	// Count selected files across allEntries
+	// Count selected files across all entries
 	updateSelectedCount = func() {
-		count := 0
-		for _, e := range allEntries {
-			if e.Selected && !e.Item.IsDir {
-				count++
+		count, unknown := 0, 0
+		var total int64
+		for _, f := range entries.Files(true) {
+			count++
+			total += f.Size
+			if f.Size == 0 {
+				unknown++
 			}
 		}
-		selectedCountLabel.SetText(fmt.Sprintf("Selected files: %d", count))
-	}
-
-	applyFilter = func(term string) {
-		term = strings.ToLower(strings.TrimSpace(term))
-		filteredIdx = filteredIdx[:0]
-
-		for i, e := range allEntries {
-			if term == "" || strings.Contains(strings.ToLower(e.Item.Name), term) {
-				filteredIdx = append(filteredIdx, i)
+		text := fmt.Sprintf("Selected files: %d", count)
+		if total > 0 {
+			text += " (" + util.FormatBytes(total, 2)
+			if unknown > 0 {
+				text += fmt.Sprintf(" + %d of unknown size", unknown)
 			}
+			text += ")"
 		}
+		selectedCountLabel.SetText(text)
+	}
 
-		selectedListIndex = -1
+	// refreshEntries redraws the list after its contents changed.
+	refreshEntries := func() {
+		list.UnselectAll()
 		list.Refresh()
 		updateSelectedCount()
+	}
 
-		if len(allEntries) > 0 {
-			statusLabel.SetText(
-				fmt.Sprintf("Showing %d of %d entries", len(filteredIdx), len(allEntries)),
-			)
+	searchEntry.OnChanged = func(term string) {
+		entries.Filter(term)
+		refreshEntries()
+
+		if shown, total := entries.Counts(); total > 0 {
+			statusLabel.SetText(fmt.Sprintf("Showing %d of %d entries", shown, total))
 		}
 	}
 
-	searchEntry.OnChanged = func(s string) {
-		applyFilter(s)
+	// loading is set while a page load or crawl runs, as both fill the
+	// list; stopCrawl cancels a running crawl. The pump clears them.
+	var loadMu sync.Mutex
+	loading := false
+	var stopCrawl context.CancelFunc
+
+	// startLoading claims the list for a new load; false if one is running.
+	startLoading := func(stop context.CancelFunc) bool {
+		loadMu.Lock()
+		defer loadMu.Unlock()
+		if loading {
+			return false
+		}
+		loading, stopCrawl = true, stop
+		return true
+	}
+
+	// finishLoading re-enables the load buttons. Runs on the UI pump.
+	finishLoading := func() {
+		loadMu.Lock()
+		loading, stopCrawl = false, nil
+		loadMu.Unlock()
+		loadBtn.Enable()
+		crawlBtn.Enable()
+		crawlBtn.SetText("Load recursively…")
 	}
 
+	// loadIndex lists one page in the background and shows it when done.
 	loadIndex := func() {
 		u := urlEntry.Text
 		if u == "" {
 			dialog.ShowInformation("Info", "Please enter a URL first.", w)
 			return
 		}
-
-		if rootURL == "" {
-			// First URL loaded becomes the root for system detection.
-			rootURL = u
-			console.Log(fmt.Sprintf("Root URL set to: %s", rootURL))
+		if !startLoading(nil) {
+			return
 		}
+		changeConf(func(c *settings.Settings) { c.LastURL = u })
 
+		loadBtn.Disable()
+		crawlBtn.Disable()
 		statusLabel.SetText("Loading: " + u)
 
-		// Synchronous request
-		res, err := httpIdx.List(u)
-		if err != nil {
-			dialog.ShowError(err, w)
-			statusLabel.SetText("Error: " + err.Error())
+		go func() {
+			res, err := httpIdx.List(u)
+			pump.Do(func() {
+				finishLoading()
+				if err != nil {
+					dialog.ShowError(err, w)
+					statusLabel.SetText("Error: " + err.Error())
+					return
+				}
+
+				found := make([]selectableEntry, len(res))
+				for i, fe := range res {
+					found[i] = selectableEntry{Item: fe}
+				}
+				state.SetListRoot(u)
+				entries.Set(found)
+				refreshEntries()
+
+				statusLabel.SetText(fmt.Sprintf("Loaded %d entries from %s", len(found), u))
+				console.Log(fmt.Sprintf("Page has %d entries (files + dirs).", len(found)))
+			})
+		}()
+	}
+
+	loadBtn.OnTapped = loadIndex
+
+	// crawlIndex walks the URL and every folder below it and lists all files
+	// found. The walk runs in the background; crawlBtn stops it.
+	crawlIndex := func(u string, opts scraper.WalkOptions) {
+		ctx, cancel := context.WithCancel(context.Background())
+		if !startLoading(cancel) {
+			cancel()
 			return
 		}
+		crawlBtn.SetText("Stop crawl")
+		loadBtn.Disable()
+		console.Log("Crawling " + u)
+
+		go func() {
+			defer cancel()
+
+			var found []selectableEntry
+			dirs, failed := 0, 0
+			err := httpIdx.Walk(ctx, u, opts, func(e scraper.WalkEntry, err error) error {
+				if err != nil {
+					failed++
+					console.LogError(fmt.Sprintf("Listing %s failed: %v", e.Path, err))
+					return nil
+				}
+				if e.IsDir {
+					dirs++
+				} else {
+					found = append(found, selectableEntry{Item: e.FileEntry, Path: e.Path})
+				}
+				msg := fmt.Sprintf("Crawling… %d files in %d folders", len(found), dirs)
+				pump.Throttle("status", func() { statusLabel.SetText(msg) })
+				return nil
+			})
+
+			pump.Do(func() {
+				finishLoading()
+
+				stopped := errors.Is(err, context.Canceled)
+				if err != nil && !stopped {
+					dialog.ShowError(err, w)
+					statusLabel.SetText("Error: " + err.Error())
+					return
+				}
+
+				state.SetListRoot(u)
+				entries.Set(found)
+				refreshEntries()
+
+				msg := fmt.Sprintf("Found %d files in %d folders under %s", len(found), dirs, u)
+				if stopped {
+					msg = "Crawl stopped. " + msg
+				}
+				if failed > 0 {
+					msg += fmt.Sprintf(" (%d folders could not be listed)", failed)
+				}
+				statusLabel.SetText(msg)
+				console.Log(msg)
+			})
+		}()
+	}
 
-		allEntries = make([]selectableEntry, len(res))
-		for i, fe := range res {
-			allEntries[i] = selectableEntry{Item: fe, Selected: false}
+	crawlBtn.OnTapped = func() {
+		loadMu.Lock()
+		stop := stopCrawl
+		loadMu.Unlock()
+		if stop != nil {
+			stop()
+			return
 		}
-		// initial filtered view: everything
-		filteredIdx = make([]int, len(allEntries))
-		for i := range filteredIdx {
-			filteredIdx[i] = i
+		u := urlEntry.Text
+		if u == "" {
+			dialog.ShowInformation("Info", "Please enter a URL first.", w)
+			return
 		}
 
-		selectedListIndex = -1
-		list.Refresh()
-		updateSelectedCount()
+		depthEntry := widget.NewEntry()
+		depthEntry.SetPlaceHolder("0 = unlimited")
+		includeEntry := widget.NewEntry()
+		includeEntry.SetPlaceHolder("e.g. *.zip, *(USA)*")
+		excludeEntry := widget.NewEntry()
+		excludeEntry.SetPlaceHolder("e.g. *(Beta)*, *(Demo)*")
+
+		items := []*widget.FormItem{
+			widget.NewFormItem("Max depth", depthEntry),
+			widget.NewFormItem("Include files", includeEntry),
+			widget.NewFormItem("Exclude", excludeEntry),
+		}
+		dialog.ShowForm("Load recursively", "Start", "Cancel", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			depth := 0
+			if text := strings.TrimSpace(depthEntry.Text); text != "" {
+				d, err := strconv.Atoi(text)
+				if err != nil || d < 0 {
+					dialog.ShowError(fmt.Errorf("invalid max depth: %s", text), w)
+					return
+				}
+				depth = d
+			}
+			crawlIndex(u, scraper.WalkOptions{
+				Workers:  4,
+				MaxDepth: depth,
+				Include:  splitPatterns(includeEntry.Text),
+				Exclude:  splitPatterns(excludeEntry.Text),
+			})
+		}, w)
+	}
+
+	// ---------- DOWNLOAD QUEUE ----------
+
+	// Every download, single or bulk, goes through one long-lived queue so it
+	// runs off the UI thread and can be cancelled.
+	dlQueue := download.NewQueue(dlMgr, maxConcurrent, 0)
 
-		statusLabel.SetText(
-			fmt.Sprintf("Loaded %d entries from %s", len(allEntries), u),
+	// Jobs are mirrored into a JSON file under the user config dir so
+	// unfinished work survives closing the window.
+	var jobStore *jobstore.Store
+	if path, err := jobstore.DefaultPath(); err != nil {
+		console.LogError("Job history disabled: " + err.Error())
+	} else if jobStore, err = jobstore.Open(path); err != nil {
+		console.LogError("Job history disabled: " + err.Error())
+	}
+	jobStoreErrLogged := false
+
+	// queueMu guards queueIdleLogged and jobStoreErrLogged, which job
+	// goroutines, the pump and button callbacks all touch.
+	var queueMu sync.Mutex
+	queueIdleLogged := true
+
+	queueTable := newQueueTable(dlQueue, func(err error) {
+		dialog.ShowError(err, w)
+	})
+
+	// refreshQueueView recomputes the queue table, progress bar and status.
+	// Runs on the UI pump.
+	refreshQueueView := func() {
+		queueTable.Refresh()
+
+		stats := dlQueue.Stats()
+		total := stats.Total()
+		if total == 0 {
+			return
+		}
+
+		// Count running jobs fractionally so a single large file still
+		// moves the bar byte by byte.
+		done := float64(stats.Finished())
+		var running *download.Job
+		for _, j := range dlQueue.Jobs() {
+			if j.State() != download.JobRunning {
+				continue
+			}
+			running = j
+			if p := j.Progress(); p.BytesTotal > 0 {
+				done += float64(p.BytesDone) / float64(p.BytesTotal)
+			}
+		}
+		ratio := done / float64(total)
+		if ratio < 0 {
+			ratio = 0
+		}
+		if ratio > 1 {
+			ratio = 1
+		}
+		progressBar.Show()
+		progressBar.SetValue(ratio)
+
+		if stats.Running == 1 && stats.Queued == 0 && running != nil {
+			statusLabel.SetText(fmt.Sprintf("%s: %s", running.Name, jobSummary(running)))
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf(
+			"Queue: %d / %d (%.1f%%) – %d running, %d paused, %d failed, %d cancelled",
+			stats.Finished(), total, ratio*100.0, stats.Running, stats.Paused, stats.Failed, stats.Cancelled,
+		))
+
+		queueMu.Lock()
+		defer queueMu.Unlock()
+		if !stats.Busy() && stats.Paused == 0 && !queueIdleLogged {
+			queueIdleLogged = true
+			statusLabel.SetText(fmt.Sprintf(
+				"All downloads finished: %d done, %d failed, %d cancelled.",
+				stats.Done, stats.Failed, stats.Cancelled,
+			))
+			console.Log("All queued downloads finished.")
+		}
+	}
+
+	dlQueue.OnUpdate = func(j *download.Job) {
+		if jobStore != nil {
+			queueMu.Lock()
+			if err := jobStore.Track(j); err != nil && !jobStoreErrLogged {
+				jobStoreErrLogged = true
+				console.LogError("Saving job history: " + err.Error())
+			}
+			queueMu.Unlock()
+		}
+
+		// Progress ticks arrive for every 32 KiB chunk; the pump redraws
+		// at most once per tick however many came in.
+		pump.Throttle("queue", refreshQueueView)
+	}
+
+	// enqueue adds files to the download queue, sorted into system folders.
+	// targetDirFor returns the local folder a file is saved in and the
+	// system folder it goes in, "" if none. Crawled files keep the folders
+	// they were found in below the crawled URL, so files of the same name
+	// in different folders don't overwrite each other.
+	targetDirFor := func(e selectableEntry) (string, string) {
+		dir := baseDownloadDir
+		folder, sorted := state.Folder(e.Item.URL)
+		if sorted && folder != "" {
+			dir = filepath.Join(dir, filepath.FromSlash(folder))
+		}
+		if sub := filepath.FromSlash(path.Dir(e.Path)); e.Path != "" && filepath.IsLocal(sub) {
+			dir = filepath.Join(dir, sub)
+		}
+		return dir, folder
+	}
+
+	enqueue := func(files []selectableEntry) {
+		queueMu.Lock()
+		if stats := dlQueue.Stats(); !stats.Busy() && stats.Paused == 0 {
+			// Start a fresh batch so the counters only cover this run.
+			dlQueue.ClearFinished()
+		}
+		queueIdleLogged = false
+		queueMu.Unlock()
+
+		for _, e := range files {
+			f := e.Item
+			targetDir, systemName := targetDirFor(e)
+			if len(files) == 1 {
+				if targetDir != baseDownloadDir {
+					console.Log(fmt.Sprintf("Detected system: %s (target: %s)", systemName, targetDir))
+				} else {
+					console.Log("System could not be determined. Using base target directory.")
+				}
+			}
+			queueTable.SetSystem(dlQueue.Add(f.Name, f.URL, targetDir), systemName)
+		}
+	}
+
+	// restoreUnfinished offers to pick up jobs left over from the last session.
+	restoreUnfinished := func() {
+		if jobStore == nil {
+			return
+		}
+		unfinished := jobStore.Unfinished()
+		if len(unfinished) == 0 {
+			return
+		}
+		dialog.ShowConfirm(
+			"Unfinished downloads",
+			fmt.Sprintf("%d downloads from the last session did not finish.\nResume them?", len(unfinished)),
+			func(ok bool) {
+				if !ok {
+					if err := jobStore.Discard(unfinished); err != nil {
+						console.LogError("Saving job history: " + err.Error())
+					}
+					return
+				}
+				queueMu.Lock()
+				queueIdleLogged = false
+				queueMu.Unlock()
+				for _, r := range unfinished {
+					j := dlQueue.Restore(r.Name, r.URL, r.TargetDir, r.Paused(), r.Attempts)
+					queueTable.SetSystem(j, filepath.Base(r.TargetDir))
+				}
+				console.Log(fmt.Sprintf("Restored %d downloads from the last session.", len(unfinished)))
+			},
+			w,
 		)
-		console.Log(fmt.Sprintf("Page has %d entries (files + dirs).", len(allEntries)))
 	}
 
-	loadBtn.OnTapped = loadIndex
+	historyBtn := widget.NewButton("History…", func() {
+		if jobStore == nil {
+			dialog.ShowInformation("Info", "Job history is not available.", w)
+			return
+		}
+		showHistoryDialog(w, jobStore)
+	})
+
+	cancelAllBtn := widget.NewButton("Cancel all", func() {
+		dlQueue.CancelAll()
+		console.Log("Cancelling all queued downloads.")
+	})
+
+	// Paused jobs give their slot back, so Pause all frees the bandwidth
+	// without losing the queue or the bytes already fetched.
+	pauseAllBtn := widget.NewButton("Pause all", func() {
+		dlQueue.PauseAll()
+		console.Log("Pausing all downloads.")
+	})
+
+	resumeAllBtn := widget.NewButton("Resume all", func() {
+		dlQueue.ResumeAll()
+		console.Log("Resuming all paused downloads.")
+	})
+
+	retryFailedBtn := widget.NewButton("Retry failed", func() {
+		n := 0
+		for _, j := range dlQueue.Jobs() {
+			if j.State() == download.JobFailed {
+				dlQueue.Retry(j)
+				n++
+			}
+		}
+		if n > 0 {
+			queueMu.Lock()
+			queueIdleLogged = false
+			queueMu.Unlock()
+			console.Log(fmt.Sprintf("Retrying %d failed downloads.", n))
+		}
+	})
+
+	clearFinishedBtn := widget.NewButton("Clear finished", func() {
+		dlQueue.ClearFinished()
+		pump.Throttle("queue", refreshQueueView)
+	})
+
+	// Fixed-height area for the queue table; a bare Table in a VBox
+	// collapses to a single row.
+	queueSpacer := canvas.NewRectangle(color.Transparent)
+	queueSpacer.SetMinSize(fyne.NewSize(0, 180))
+	queueView := container.NewStack(queueSpacer, queueTable)
 
 	// ---------- ACTION BUTTONS ----------
 
 	// Navigate remote directory (Myrient side)
 	openRemoteDirBtn := widget.NewButton("Open remote directory", func() {
-		if selectedListIndex < 0 || selectedListIndex >= len(filteredIdx) {
+		e, ok := entries.Highlighted()
+		if !ok {
 			dialog.ShowInformation("Info", "Select a directory entry first.", w)
 			return
 		}
-		globalIdx := filteredIdx[selectedListIndex]
-		e := allEntries[globalIdx]
 
 		if !e.Item.IsDir {
 			dialog.ShowInformation("Info", "Selected item is not a directory.", w)
@@ -289,6 +711,13 @@ func Run() {
 		loadIndex()
 	})
 
+	mirrorsBtn := widget.NewButton("Mirrors…", func() {
+		showMirrorsDialog(w, mirrors, func(bases []string) {
+			changeConf(func(c *settings.Settings) { c.Mirrors = bases })
+			console.Log(fmt.Sprintf("Using %d mirrors.", len(bases)))
+		})
+	})
+
 	// Choose local download directory once
 	setDownloadDirBtn := widget.NewButton("Set download folder…", func() {
 		fd := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
@@ -296,206 +725,422 @@ func Run() {
 				return
 			}
 			baseDownloadDir = uri.Path()
+			changeConf(func(c *settings.Settings) { c.DownloadDir = baseDownloadDir })
 			statusLabel.SetText("Download folder set to: " + baseDownloadDir)
 			console.Log("Download folder set to: " + baseDownloadDir)
 		}, w)
 		fd.Show()
 	})
 
+	// Load .sfv/.md5/.sha1 sidecars so matching downloads get verified
+	loadChecksumsBtn := widget.NewButton("Load checksums…", func() {
+		fd := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			path := rc.URI().Path()
+			rc.Close()
+
+			n, err := checksumIdx.LoadChecksumFile(path)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			statusLabel.SetText(fmt.Sprintf("Loaded %d checksums (%d files known)", n, checksumIdx.Len()))
+			console.Log(fmt.Sprintf("Loaded %d checksums from %s.", n, path))
+		}, w)
+		fd.Show()
+	})
+
+	// Load a No-Intro/Redump DAT to select by it and verify against it
+	loadDatBtn := widget.NewButton("Load DAT…", func() {
+		fd := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			path := rc.URI().Path()
+			rc.Close()
+
+			d, err := dat.Load(path)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			datIdx = dat.NewIndex(d)
+			dlMgr.SetChecksumSource(download.ChecksumSources{checksumIdx, download.DATSource(datIdx)})
+
+			name := d.Header.Name
+			if name == "" {
+				name = filepath.Base(path)
+			}
+			statusLabel.SetText(fmt.Sprintf("DAT loaded: %s (%d games)", name, len(d.Games)))
+			console.Log(fmt.Sprintf("Loaded DAT %s %s with %d games.", name, d.Header.Version, len(d.Games)))
+		}, w)
+		fd.Show()
+	})
+
+	// selectByDat checks every listed file that's in the DAT; with
+	// missingOnly, only those not already in the download folder.
+	selectByDat := func(missingOnly bool) {
+		if datIdx == nil {
+			dialog.ShowInformation("Info", "Load a DAT first.", w)
+			return
+		}
+		if missingOnly && baseDownloadDir == "" {
+			dialog.ShowInformation("Info", "Set a download folder first.", w)
+			return
+		}
+
+		matched, selected := 0, 0
+		entries.Each(func(e *selectableEntry) {
+			if e.Item.IsDir {
+				return
+			}
+			g, ok := datIdx.GameFor(e.Item.Name)
+			if !ok {
+				return
+			}
+			matched++
+			if missingOnly {
+				dir, _ := targetDirFor(*e)
+				if haveLocally(dir, e.Item.Name, g) {
+					return
+				}
+			}
+			e.Selected = true
+			selected++
+		})
+		list.Refresh()
+		updateSelectedCount()
+
+		_, total := entries.Counts()
+		statusLabel.SetText(fmt.Sprintf(
+			"%d of %d listed files are in the DAT; selected %d",
+			matched, total, selected,
+		))
+	}
+
+	selectDatBtn := widget.NewButton("Select DAT games", func() { selectByDat(false) })
+	selectMissingBtn := widget.NewButton("Select missing", func() { selectByDat(true) })
+
+	// Audit the download folder for the listed system against the loaded DAT,
+	// or against the current listing when no DAT is loaded.
+	auditBtn := widget.NewButton("Audit…", func() {
+		if baseDownloadDir == "" {
+			dialog.ShowInformation("Info", "Set a download folder first.", w)
+			return
+		}
+		if _, total := entries.Counts(); datIdx == nil && total == 0 {
+			dialog.ShowInformation("Info", "Load a DAT or a remote listing first.", w)
+			return
+		}
+
+		dir := baseDownloadDir
+		files := entries.Files(false)
+		if len(files) > 0 {
+			dir, _ = targetDirFor(selectableEntry{Item: files[0]})
+		}
+		idx, source := datIdx, urlEntry.Text
+
+		statusLabel.SetText("Auditing " + dir + "…")
+		go func() {
+			var rep *audit.Report
+			var err error
+			if idx != nil {
+				rep, err = audit.AgainstDAT(dir, idx)
+			} else {
+				rep, err = audit.AgainstListing(dir, source, files)
+			}
+			pump.Do(func() {
+				if err != nil {
+					statusLabel.SetText("Audit failed: " + err.Error())
+					console.LogError("Audit failed: " + err.Error())
+					return
+				}
+				statusLabel.SetText("Audit: " + rep.Summary())
+				console.Log(fmt.Sprintf("Audited %s: %s", dir, rep.Summary()))
+				showAuditDialog(w, rep)
+			})
+		}()
+	})
+
 	// Concurrency controls
 	concurrencyLabel := widget.NewLabel(fmt.Sprintf("Concurrent downloads: %d", maxConcurrent))
-	concurrencySlider := widget.NewSlider(1, 100)
+	concurrencySlider := widget.NewSlider(1, settings.MaxConcurrency)
 	concurrencySlider.Step = 1
 	concurrencySlider.SetValue(float64(maxConcurrent))
 	concurrencySlider.OnChanged = func(v float64) {
 		maxConcurrent = int(v)
 		concurrencyLabel.SetText(fmt.Sprintf("Concurrent downloads: %d", maxConcurrent))
+		dlQueue.SetConcurrency(maxConcurrent)
+		changeConf(func(c *settings.Settings) { c.Concurrency = maxConcurrent })
 	}
 
+	// Connections per file: >1 splits large files into parallel byte ranges
+	// when the server supports it.
+	dlMgr.SetSegments(conf.Connections)
+	segmentsLabel := widget.NewLabel(fmt.Sprintf("Connections per file: %d", conf.Connections))
+	segmentsSlider := widget.NewSlider(1, settings.MaxConnections)
+	segmentsSlider.Step = 1
+	segmentsSlider.SetValue(float64(conf.Connections))
+	segmentsSlider.OnChanged = func(v float64) {
+		dlMgr.SetSegments(int(v))
+		segmentsLabel.SetText(fmt.Sprintf("Connections per file: %d", int(v)))
+		changeConf(func(c *settings.Settings) { c.Connections = int(v) })
+	}
+
+	// Bandwidth limit shared by every transfer; applied live.
+	limitEntry := widget.NewEntry()
+	limitEntry.SetPlaceHolder("0 = unlimited")
+	limitUnit := widget.NewSelect([]string{"KB/s", "MB/s"}, nil)
+	// setLimitFields applies a rate from the settings and shows it,
+	// without the fields' handlers seeing the half-updated pair.
+	setLimitFields := func(rate string) {
+		bps, _ := settings.Settings{RateLimit: rate}.RateLimitBytes()
+		dlMgr.SetRateLimit(bps)
+		onText, onUnit := limitEntry.OnChanged, limitUnit.OnChanged
+		limitEntry.OnChanged, limitUnit.OnChanged = nil, nil
+		text, unit := rateLimitFields(bps)
+		limitEntry.SetText(text)
+		limitUnit.SetSelected(unit)
+		limitEntry.OnChanged, limitUnit.OnChanged = onText, onUnit
+	}
+	setLimitFields(conf.RateLimit)
+	applyRateLimit := func() {
+		text := strings.TrimSpace(limitEntry.Text)
+		v := 0.0
+		if text != "" {
+			f, err := strconv.ParseFloat(text, 64)
+			if err != nil || f < 0 {
+				statusLabel.SetText("Invalid bandwidth limit: " + text)
+				return
+			}
+			v = f
+		}
+		mult := 1024.0
+		if limitUnit.Selected == "MB/s" {
+			mult = 1024 * 1024
+		}
+		bps := int64(v * mult)
+		dlMgr.SetRateLimit(bps)
+		rate := ""
+		if bps > 0 {
+			rate = text + strings.TrimSuffix(limitUnit.Selected, "B/s")
+		}
+		changeConf(func(c *settings.Settings) { c.RateLimit = rate })
+		if bps == 0 {
+			statusLabel.SetText("Bandwidth limit: unlimited")
+		} else {
+			statusLabel.SetText("Bandwidth limit: " + util.FormatBytes(bps, 1) + "/s")
+		}
+	}
+	limitEntry.OnChanged = func(string) { applyRateLimit() }
+	limitUnit.OnChanged = func(string) { applyRateLimit() }
+	limitRow := container.NewBorder(nil, nil, widget.NewLabel("Bandwidth limit"), limitUnit, limitEntry)
+
+	settingsBtn := widget.NewButton("Settings…", func() {
+		showSettingsDialog(w, state.Conf(), func(s settings.Settings) {
+			changeConf(func(c *settings.Settings) { *c = s })
+
+			baseDownloadDir = s.DownloadDir
+			concurrencySlider.SetValue(float64(s.Concurrency))
+			segmentsSlider.SetValue(float64(s.Connections))
+			setLimitFields(s.RateLimit)
+			dlMgr.SetExtract(s.Extract)
+			applyRetries(s.Retries)
+			mirrors.SetBases(s.Mirrors)
+
+			statusLabel.SetText("Settings saved.")
+			console.Log("Settings saved.")
+		})
+	})
+
+	// Root URL, layout profile and folder rules, previewed on the checked
+	// files (or all listed ones if none are checked)
+	foldersBtn := widget.NewButton("Folders…", func() {
+		files := entries.Files(true)
+		if len(files) == 0 {
+			files = entries.Files(false)
+		}
+		showFoldersDialog(w, state.Conf(), state.ListRoot(), files, func(root string, rules []sysmap.Rule, profile string) {
+			m, err := state.SetFolders(root, rules, profile)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			dlMgr.SetExtractRule(m.Unzip)
+			savePreferences(prefs, state.Conf())
+
+			msg := fmt.Sprintf("Folder rules saved: %d rules", len(rules))
+			if p := m.Profile(); p != nil {
+				msg += ", " + p.Name + " layout"
+			}
+			if root != "" {
+				msg += ", root " + root
+			}
+			statusLabel.SetText(msg)
+			console.Log(msg)
+		})
+	})
+
+	// Move an existing library's system folders to where the profile
+	// wants them
+	reorganizeBtn := widget.NewButton("Reorganize…", func() {
+		p := state.Folders().Profile()
+		switch {
+		case p == nil:
+			dialog.ShowInformation("Info", "Choose a frontend profile under Folders… first.", w)
+			return
+		case baseDownloadDir == "":
+			dialog.ShowInformation("Info", "Set a download folder first.", w)
+			return
+		}
+		dir := baseDownloadDir
+
+		statusLabel.SetText("Scanning " + dir + "…")
+		go func() {
+			plan, err := organize.PlanLibrary(dir, p)
+			pump.Do(func() {
+				if err != nil {
+					statusLabel.SetText("Scan failed: " + err.Error())
+					console.LogError("Scan failed: " + err.Error())
+					return
+				}
+				statusLabel.SetText(plan.Summary())
+				showOrganizeDialog(w, plan, func() {
+					statusLabel.SetText("Reorganizing " + dir + "…")
+					go func() {
+						err := plan.Apply(console.Log)
+						pump.Do(func() {
+							if err != nil {
+								statusLabel.SetText("Reorganized with errors; see the log.")
+								return
+							}
+							statusLabel.SetText("Reorganized " + dir + " for " + p.Name + ".")
+						})
+					}()
+				})
+			})
+		}()
+	})
+
 	// Single-file download (uses baseDownloadDir) with byte progress + ETA
 	downloadBtn := widget.NewButton("Download file…", func() {
 		if baseDownloadDir == "" {
 			dialog.ShowInformation("Info", "Set a download folder first.", w)
 			return
 		}
-		if selectedListIndex < 0 || selectedListIndex >= len(filteredIdx) {
+		e, ok := entries.Highlighted()
+		if !ok {
 			dialog.ShowInformation("Info", "Select a file entry first.", w)
 			return
 		}
 
-		globalIdx := filteredIdx[selectedListIndex]
-		e := allEntries[globalIdx]
-
 		if e.Item.IsDir {
 			dialog.ShowInformation("Info", "Selected item is a directory.", w)
 			return
 		}
 
-		baseTargetDir := baseDownloadDir
-
-		// Figure out the system/console folder from URLs.
-		systemName := util.GuessSystemFromURL(rootURL, e.Item.URL)
-		targetDir := baseTargetDir
-		if systemName != "" && systemName != "Unknown" {
-			targetDir = filepath.Join(baseTargetDir, systemName)
-			console.Log(fmt.Sprintf("Detected system: %s (target: %s)", systemName, targetDir))
-		} else {
-			console.Log("System could not be determined. Using base target directory.")
-		}
-
-		progressBar.SetValue(0)
-		progressBar.Show()
 		statusLabel.SetText("Starting download...")
-
-		start := time.Now()
-
-		if err := dlMgr.DownloadFile(e.Item.URL, targetDir, func(p download.Progress) {
-			if p.Err != nil {
-				statusLabel.SetText("Error: " + p.Err.Error())
-				progressBar.Hide()
-				return
-			}
-			if p.BytesTotal > 0 {
-				ratio := float64(p.BytesDone) / float64(p.BytesTotal)
-				if ratio < 0 {
-					ratio = 0
-				}
-				if ratio > 1 {
-					ratio = 1
-				}
-				progressBar.SetValue(ratio)
-				eta := util.CalculateETA(p.BytesDone, p.BytesTotal, start)
-				statusLabel.SetText(
-					fmt.Sprintf(
-						"%s / %s (ETA %s)",
-						util.FormatBytes(p.BytesDone, 2),
-						util.FormatBytes(p.BytesTotal, 2),
-						eta,
-					),
-				)
-			}
-			if p.Done {
-				progressBar.SetValue(1)
-				statusLabel.SetText("Download complete.")
-			}
-		}); err != nil {
-			statusLabel.SetText("Error: " + err.Error())
-		}
+		enqueue([]selectableEntry{e})
 	})
 
 	// Select all / clear buttons
 	selectAllBtn := widget.NewButton("Select all", func() {
-		for i := range allEntries {
-			allEntries[i].Selected = true
-		}
+		entries.Each(func(e *selectableEntry) { e.Selected = true })
 		list.Refresh()
 		updateSelectedCount()
 	})
 
 	clearSelectionBtn := widget.NewButton("Clear selection", func() {
-		for i := range allEntries {
-			allEntries[i].Selected = false
-		}
-		selectedListIndex = -1
-		list.Refresh()
-		updateSelectedCount()
+		entries.Each(func(e *selectableEntry) { e.Selected = false })
+		refreshEntries()
 	})
 
-	// Bulk download of all checked files – WITH semaphores (concurrency + retry)
-	downloadSelectedBtn := widget.NewButton("Download selected…", func() {
-		if baseDownloadDir == "" {
-			dialog.ShowInformation("Info", "Set a download folder first.", w)
-			return
-		}
-
-		var toDownload []domain.FileEntry
-		for _, e := range allEntries {
-			if e.Selected && !e.Item.IsDir {
-				toDownload = append(toDownload, e.Item)
-			}
-		}
+	// systemOf is the system a listed file is sorted into, "" if unknown.
+	systemOf := func(f domain.FileEntry) string {
+		_, name := targetDirFor(selectableEntry{Item: f})
+		return name
+	}
 
-		if len(toDownload) == 0 {
+	// Save the checked files as a list to share or load again later
+	saveSelectionBtn := widget.NewButton("Save selection…", func() {
+		files := entries.Files(true)
+		if len(files) == 0 {
 			dialog.ShowInformation("Info", "No files selected.", w)
 			return
 		}
-
-		baseTargetDir := baseDownloadDir
-		total := len(toDownload)
-
-		type downloadResult struct {
-			name string
-			err  error
+		l := &selection.List{Source: urlEntry.Text, Created: time.Now()}
+		for _, f := range files {
+			l.Entries = append(l.Entries, selection.Entry{
+				URL:    f.URL,
+				Name:   f.Name,
+				Size:   f.Size,
+				System: systemOf(f),
+			})
 		}
+		saveSelectionFile(w, l)
+	})
 
-		// Semaphore to limit concurrent downloads
-		sem := make(chan struct{}, maxConcurrent)
-
-		// Channel to receive completion events
-		doneCh := make(chan downloadResult)
-
-		progressBar.Show()
-		progressBar.SetValue(0)
-
-		console.Log(fmt.Sprintf("Starting bulk download of %d files with concurrency %d", total, maxConcurrent))
-
-		// Kick off all jobs (goroutines are limited by sem)
-		for _, f := range toDownload {
-			f := f // capture loop variable
-
-			systemName := util.GuessSystemFromURL(rootURL, f.URL)
-			targetDir := baseTargetDir
-			if systemName != "" && systemName != "Unknown" {
-				targetDir = filepath.Join(baseTargetDir, systemName)
+	// Check the files of a saved list that are in the current listing
+	loadSelectionBtn := widget.NewButton("Load selection…", func() {
+		openSelectionFile(w, func(l *selection.List) {
+			if _, total := entries.Counts(); total == 0 {
+				if l.Source != "" {
+					urlEntry.SetText(l.Source)
+				}
+				dialog.ShowInformation("Info", "Load the index the list was made from first, then load the list again.", w)
+				return
 			}
 
-			go func(name, url, td string) {
-				sem <- struct{}{} // acquire slot
-				err := dlMgr.DownloadFileWithRetry(url, td, nil, 3)
-				<-sem // release slot
-				doneCh <- downloadResult{name: name, err: err}
-			}(f.Name, f.URL, targetDir)
-		}
+			res := selection.Resolve(l, entries.Files(false), systemOf)
+			urls := make(map[string]bool, len(res.Found))
+			for _, f := range res.Found {
+				urls[f.URL] = true
+			}
+			entries.CheckURLs(urls)
+			list.Refresh()
+			updateSelectedCount()
 
-		// Collect results and update queue progress in the main goroutine
-		completed := 0
-		for i := 0; i < total; i++ {
-			res := <-doneCh
-			completed++
-			ratio := float64(completed) / float64(total)
-			if ratio < 0 {
-				ratio = 0
+			msg := fmt.Sprintf("Selection %q: selected %d of %d files", l.Name, len(res.Found), len(l.Entries))
+			if len(res.Missing) > 0 {
+				msg += fmt.Sprintf(", %d not found", len(res.Missing))
 			}
-			if ratio > 1 {
-				ratio = 1
+			statusLabel.SetText(msg)
+			console.Log(msg)
+			for _, e := range res.Missing {
+				console.LogError("Not in listing: " + e.Name)
 			}
-			progressBar.SetValue(ratio)
-
-			initial := ""
-			if len(res.name) > 0 {
-				initial = strings.ToUpper(string(res.name[0]))
+			if len(res.Missing) > 0 {
+				showMissingEntries(w, l, res)
 			}
+		})
+	})
 
-			if res.err != nil {
-				statusLabel.SetText(
-					fmt.Sprintf(
-						"Queue: %d / %d (%.1f%%, @ %s) – ERROR %s: %v",
-						completed, total, ratio*100.0, initial, res.name, res.err,
-					),
-				)
-				console.LogError(fmt.Sprintf("Error downloading %s: %v", res.name, res.err))
-			} else {
-				statusLabel.SetText(
-					fmt.Sprintf(
-						"Queue: %d / %d (%.1f%%, @ %s) – finished %s",
-						completed, total, ratio*100.0, initial, res.name,
-					),
-				)
-			}
+	// Bulk download of all checked files through the queue (concurrency + retry)
+	downloadSelectedBtn := widget.NewButton("Download selected…", func() {
+		if baseDownloadDir == "" {
+			dialog.ShowInformation("Info", "Set a download folder first.", w)
+			return
+		}
+
+		toDownload := entries.Checked()
+
+		if len(toDownload) == 0 {
+			dialog.ShowInformation("Info", "No files selected.", w)
+			return
 		}
 
-		statusLabel.SetText("All selected downloads completed.")
-		console.Log("All selected downloads completed.")
+		var total int64
+		for _, e := range toDownload {
+			total += e.Item.Size
+		}
+		console.Log(fmt.Sprintf("Starting bulk download of %d files with concurrency %d", len(toDownload), maxConcurrent))
+		if total > 0 {
+			// Listing sizes are rounded, so this is an estimate.
+			console.LogTotalSize("about " + util.FormatBytes(total, 2))
+		}
+		enqueue(toDownload)
 	})
 
 	// ---------- LEFT SIDE (search + list) ----------
@@ -512,15 +1157,21 @@ func Run() {
 		widget.NewLabelWithStyle("Actions & Status", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		concurrencyLabel,
 		concurrencySlider,
+		segmentsLabel,
+		segmentsSlider,
+		limitRow,
 		openRemoteDirBtn,
-		setDownloadDirBtn,
+		container.NewHBox(setDownloadDirBtn, loadChecksumsBtn, mirrorsBtn, foldersBtn, settingsBtn),
 		downloadBtn,
 		downloadSelectedBtn,
-		container.NewHBox(selectAllBtn, clearSelectionBtn),
+		container.NewHBox(selectAllBtn, clearSelectionBtn, saveSelectionBtn, loadSelectionBtn, historyBtn),
+		container.NewHBox(loadDatBtn, selectDatBtn, selectMissingBtn, auditBtn, reorganizeBtn),
 		selectedCountLabel,
 		widget.NewSeparator(),
 		widget.NewLabelWithStyle("Progress", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		progressBar,
+		container.NewBorder(nil, nil, widget.NewLabel("Downloads"), container.NewHBox(pauseAllBtn, resumeAllBtn, retryFailedBtn, cancelAllBtn, clearFinishedBtn)),
+		queueView,
 		widget.NewSeparator(),
 		widget.NewLabelWithStyle("Status", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		statusLabel,
@@ -542,5 +1193,77 @@ func Run() {
 	)
 
 	w.SetContent(content)
+
+	w.SetOnClosed(func() {
+		if jobStore != nil {
+			jobStore.Flush()
+		}
+	})
+	restoreUnfinished()
+
 	w.ShowAndRun()
 }
+
+// jobSummary formats a job's state and byte progress for one list row.
+func jobSummary(j *download.Job) string {
+	p := j.Progress()
+	switch st := j.State(); st {
+	case download.JobRunning:
+		if !p.NextRetry.IsZero() {
+			return fmt.Sprintf(
+				"attempt %d failed (%v), retrying in %s",
+				p.Attempt, p.Err, util.FormatDuration(time.Until(p.NextRetry)),
+			)
+		}
+		speed := ""
+		if p.Speed > 0 {
+			speed = " @ " + util.FormatBytes(p.Speed, 1) + "/s"
+		}
+		if p.BytesTotal > 0 {
+			return fmt.Sprintf(
+				"%s / %s%s (ETA %s)",
+				util.FormatBytes(p.BytesDone, 2),
+				util.FormatBytes(p.BytesTotal, 2),
+				speed,
+				p.ETA,
+			)
+		}
+		return util.FormatBytes(p.BytesDone, 2) + speed
+	case download.JobPaused:
+		if p.BytesTotal > 0 {
+			return fmt.Sprintf(
+				"paused at %s / %s",
+				util.FormatBytes(p.BytesDone, 2),
+				util.FormatBytes(p.BytesTotal, 2),
+			)
+		}
+		return "paused"
+	case download.JobFailed:
+		return fmt.Sprintf("failed: %v", j.Err())
+	default:
+		return st.String()
+	}
+}
+
+// entryDetails is the size and date column text for a list row.
+func entryDetails(fe domain.FileEntry) string {
+	var parts []string
+	if fe.Size > 0 {
+		parts = append(parts, util.FormatBytes(fe.Size, 1))
+	}
+	if !fe.Modified.IsZero() {
+		parts = append(parts, fe.Modified.Format("2006-01-02 15:04"))
+	}
+	return strings.Join(parts, "   ")
+}
+
+// splitPatterns splits a comma-separated list of glob patterns.
+func splitPatterns(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
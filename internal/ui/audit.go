@@ -0,0 +1,95 @@
+// internal/ui/audit.go
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"awesomeProject1/internal/audit"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showAuditDialog shows an audit report with a status filter and CSV /
+// fixdat export.
+func showAuditDialog(w fyne.Window, rep *audit.Report) {
+	items := rep.Items
+
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(items) {
+				return
+			}
+			it := items[i]
+			text := fmt.Sprintf("[%s] %s", it.Status, it.Name)
+			if it.Detail != "" {
+				text += " — " + it.Detail
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+
+	filters := map[string]audit.Status{
+		"All":        "",
+		"Have":       audit.Have,
+		"Unverified": audit.Unverified,
+		"Missing":    audit.Miss,
+		"Bad":        audit.Bad,
+		"Extra":      audit.Extra,
+	}
+	filter := widget.NewSelect([]string{"All", "Have", "Unverified", "Missing", "Bad", "Extra"}, func(s string) {
+		items = rep.Filter(filters[s])
+		list.Refresh()
+	})
+	filter.SetSelected("All")
+
+	exportCSV := widget.NewButton("Export CSV…", func() {
+		saveReport(w, "audit.csv", rep.WriteCSV)
+	})
+	exportFixdat := widget.NewButton("Export fixdat…", func() {
+		saveReport(w, "fixdat.dat", rep.WriteFixdat)
+	})
+	if len(rep.Fixdat().Games) == 0 {
+		// Listing audits have no DAT games to put in a fixdat.
+		exportFixdat.Disable()
+	}
+
+	top := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Folder: %s", rep.Dir)),
+		widget.NewLabel(fmt.Sprintf("Source: %s", rep.Source)),
+		widget.NewLabelWithStyle(rep.Summary(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewBorder(nil, nil, widget.NewLabel("Show"), nil, filter),
+	)
+	content := container.NewBorder(top, nil, nil, nil, list)
+
+	d := dialog.NewCustom("Collection audit", "Close", content, w)
+	d.SetButtons([]fyne.CanvasObject{
+		exportCSV,
+		exportFixdat,
+		widget.NewButton("Close", d.Hide),
+	})
+	d.Resize(fyne.NewSize(800, 550))
+	d.Show()
+}
+
+// saveReport asks for a destination file and writes to it with write.
+func saveReport(w fyne.Window, defaultName string, write func(io.Writer) error) {
+	fd := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+		if err != nil || wc == nil {
+			return
+		}
+		defer wc.Close()
+		if err := write(wc); err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+	fd.SetFileName(defaultName)
+	fd.Show()
+}
@@ -0,0 +1,27 @@
+// internal/ui/datselect.go
+package ui
+
+import (
+	"os"
+	"path/filepath"
+
+	"awesomeProject1/internal/dat"
+)
+
+// haveLocally reports whether a DAT game is already in dir, either as the
+// downloaded archive or as its extracted ROM files.
+func haveLocally(dir, filename string, g *dat.Game) bool {
+	if fi, err := os.Stat(filepath.Join(dir, filename)); err == nil && fi.Size() > 0 {
+		return true
+	}
+	if len(g.ROMs) == 0 {
+		return false
+	}
+	for _, r := range g.ROMs {
+		fi, err := os.Stat(filepath.Join(dir, r.Name))
+		if err != nil || (r.Size > 0 && fi.Size() != r.Size) {
+			return false
+		}
+	}
+	return true
+}
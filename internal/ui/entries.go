@@ -0,0 +1,154 @@
+// internal/ui/entries.go
+package ui
+
+import (
+	"strings"
+	"sync"
+
+	"awesomeProject1/internal/domain"
+)
+
+// entryList is what the file list shows: every listed entry, the ones
+// matching the search term, and the highlighted row. The list widget reads
+// it from Fyne's goroutines while loads replace it from the UI pump, so
+// every access goes through its lock. Never call widget methods while
+// holding it; the list's callbacks take it too.
+type entryList struct {
+	mu       sync.Mutex
+	all      []selectableEntry
+	filtered []int // indexes into all matching term
+	term     string
+	selected int // index into filtered; -1 = none
+}
+
+func newEntryList() *entryList {
+	return &entryList{selected: -1}
+}
+
+// Set replaces the entries, keeping the current search term.
+func (l *entryList) Set(entries []selectableEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.all = entries
+	l.filterLocked()
+}
+
+// Filter shows only entries whose label contains term, ignoring case.
+func (l *entryList) Filter(term string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.term = strings.ToLower(strings.TrimSpace(term))
+	l.filterLocked()
+}
+
+func (l *entryList) filterLocked() {
+	l.filtered = l.filtered[:0]
+	for i, e := range l.all {
+		if l.term == "" || strings.Contains(strings.ToLower(e.label()), l.term) {
+			l.filtered = append(l.filtered, i)
+		}
+	}
+	l.selected = -1
+}
+
+// Len is the number of rows shown.
+func (l *entryList) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.filtered)
+}
+
+// Counts returns the number of rows shown and of entries listed.
+func (l *entryList) Counts() (shown, total int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.filtered), len(l.all)
+}
+
+// Row returns the entry shown in row i.
+func (l *entryList) Row(i int) (selectableEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if i < 0 || i >= len(l.filtered) {
+		return selectableEntry{}, false
+	}
+	return l.all[l.filtered[i]], true
+}
+
+// Check sets the checkbox of the entry shown in row i.
+func (l *entryList) Check(i int, on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if i >= 0 && i < len(l.filtered) {
+		l.all[l.filtered[i]].Selected = on
+	}
+}
+
+// Highlight records row i as the highlighted one; -1 clears it.
+func (l *entryList) Highlight(i int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.selected = i
+}
+
+// Unhighlight clears the highlight if row i has it.
+func (l *entryList) Unhighlight(i int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.selected == i {
+		l.selected = -1
+	}
+}
+
+// Highlighted returns the entry in the highlighted row.
+func (l *entryList) Highlighted() (selectableEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.selected < 0 || l.selected >= len(l.filtered) {
+		return selectableEntry{}, false
+	}
+	return l.all[l.filtered[l.selected]], true
+}
+
+// Each calls f for every listed entry, shown or not. f may change the
+// entry; it must not call back into l.
+func (l *entryList) Each(f func(e *selectableEntry)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.all {
+		f(&l.all[i])
+	}
+}
+
+// Files returns the listed files, leaving out folders; with checkedOnly,
+// only those whose checkbox is set.
+func (l *entryList) Files(checkedOnly bool) []domain.FileEntry {
+	var files []domain.FileEntry
+	l.Each(func(e *selectableEntry) {
+		if !e.Item.IsDir && (e.Selected || !checkedOnly) {
+			files = append(files, e.Item)
+		}
+	})
+	return files
+}
+
+// Checked returns the listed files whose checkbox is set, leaving out
+// folders.
+func (l *entryList) Checked() []selectableEntry {
+	var checked []selectableEntry
+	l.Each(func(e *selectableEntry) {
+		if !e.Item.IsDir && e.Selected {
+			checked = append(checked, *e)
+		}
+	})
+	return checked
+}
+
+// CheckURLs sets the checkbox of every listed entry whose URL is in urls.
+func (l *entryList) CheckURLs(urls map[string]bool) {
+	l.Each(func(e *selectableEntry) {
+		if urls[e.Item.URL] {
+			e.Selected = true
+		}
+	})
+}
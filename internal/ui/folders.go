@@ -0,0 +1,266 @@
+// internal/ui/folders.go
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/domain"
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/sysmap"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ruleRow is one editable rule in the folders dialog.
+type ruleRow struct {
+	pattern *widget.Entry
+	folder  *widget.Entry
+}
+
+func newRuleRow(r sysmap.Rule) ruleRow {
+	row := ruleRow{pattern: widget.NewEntry(), folder: widget.NewEntry()}
+	row.pattern.SetPlaceHolder(`regexp, e.g. Super Nintendo Entertainment System/`)
+	row.pattern.SetText(r.Pattern)
+	row.folder.SetPlaceHolder("folder, e.g. snes")
+	row.folder.SetText(r.Folder)
+	return row
+}
+
+// noProfile is the profile choice for none.
+const noProfile = "None"
+
+// showFoldersDialog edits the root URL, the layout profile and the folder
+// rules, previewing where files land with them. listRoot is the URL the
+// listing came from, used when no root is set. onSave gets the new root,
+// rules and profile ID.
+func showFoldersDialog(w fyne.Window, cur settings.Settings, listRoot string, files []domain.FileEntry, onSave func(root string, rules []sysmap.Rule, profile string)) {
+	rootEntry := widget.NewEntry()
+	rootEntry.SetText(cur.RootURL)
+	rootEntry.SetPlaceHolder("empty = the URL loaded")
+	useLoadedBtn := widget.NewButton("Use loaded URL", func() {
+		rootEntry.SetText(listRoot)
+	})
+	if listRoot == "" {
+		useLoadedBtn.Disable()
+	}
+
+	var rows []ruleRow
+	for _, r := range cur.Rules {
+		rows = append(rows, newRuleRow(r))
+	}
+	rulesBox := container.NewVBox()
+	var showRows func()
+	showRows = func() {
+		rulesBox.Objects = nil
+		for i, row := range rows {
+			upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+				rows[i-1], rows[i] = rows[i], rows[i-1]
+				showRows()
+			})
+			if i == 0 {
+				upBtn.Disable()
+			}
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				rows = append(rows[:i], rows[i+1:]...)
+				showRows()
+			})
+			rulesBox.Add(container.NewBorder(nil, nil,
+				widget.NewLabel(fmt.Sprintf("%d", i+1)),
+				container.NewHBox(upBtn, removeBtn),
+				container.NewGridWithColumns(2, row.pattern, row.folder),
+			))
+		}
+		rulesBox.Refresh()
+	}
+	showRows()
+	addBtn := widget.NewButtonWithIcon("Add rule", theme.ContentAddIcon(), func() {
+		rows = append(rows, newRuleRow(sysmap.Rule{}))
+		showRows()
+	})
+
+	// rules reads the rows, leaving out empty ones.
+	rules := func() []sysmap.Rule {
+		var out []sysmap.Rule
+		for _, row := range rows {
+			r := sysmap.Rule{Pattern: strings.TrimSpace(row.pattern.Text), Folder: strings.TrimSpace(row.folder.Text)}
+			if r.Pattern != "" || r.Folder != "" {
+				out = append(out, r)
+			}
+		}
+		return out
+	}
+
+	profileNames := []string{noProfile}
+	for _, p := range sysmap.Profiles {
+		profileNames = append(profileNames, p.Name)
+	}
+	profileSelect := widget.NewSelect(profileNames, nil)
+	profileSelect.SetSelected(noProfile)
+	if p, ok := sysmap.ProfileByID(cur.Profile); ok {
+		profileSelect.SetSelected(p.Name)
+	}
+	// profile is the ID of the chosen profile, "" for none.
+	profile := func() string {
+		for _, p := range sysmap.Profiles {
+			if p.Name == profileSelect.Selected {
+				return p.ID
+			}
+		}
+		return ""
+	}
+
+	presets := sysmap.Presets()
+	var presetNames []string
+	for _, p := range presets {
+		presetNames = append(presetNames, p.Name)
+	}
+	presetSelect := widget.NewSelect(presetNames, nil)
+	presetSelect.PlaceHolder = "Replace rules with a preset…"
+
+	// The preview shows each file with where it would go and why.
+	preview := make([]string, len(files))
+	previewStatus := widget.NewLabel("")
+	previewStatus.Wrapping = fyne.TextWrapWord
+	previewList := widget.NewList(
+		func() int { return len(preview) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(preview) {
+				return
+			}
+			o.(*widget.Label).SetText(preview[i])
+		},
+	)
+	base := cur.DownloadDir
+	if base == "" {
+		base = "<download folder>"
+	}
+	updatePreview := func() {
+		m, err := sysmap.New(rules(), profile())
+		if err != nil {
+			previewStatus.SetText(err.Error())
+			return
+		}
+		root := strings.TrimSpace(rootEntry.Text)
+		if root == "" {
+			root = listRoot
+		}
+		sorted, outside := 0, 0
+		for i, f := range files {
+			folder, rule := m.Match(root, f.URL)
+			dir, why := base, "unsorted"
+			switch {
+			case cur.Layout != settings.LayoutSystem:
+				why = "flat layout"
+			case folder == "" && rule >= 0:
+				why = fmt.Sprintf("rule %d", rule+1)
+			case folder == "":
+			case rule >= 0:
+				dir, why = filepath.Join(base, filepath.FromSlash(folder)), fmt.Sprintf("rule %d", rule+1)
+			case rule == sysmap.Profiled:
+				dir, why = filepath.Join(base, filepath.FromSlash(folder)), m.Profile().Name
+			default:
+				dir, why = filepath.Join(base, filepath.FromSlash(folder)), "below root"
+			}
+			if dir != base {
+				sorted++
+			}
+			if unzip, ok := m.Unzip(f.URL); ok && strings.EqualFold(filepath.Ext(f.Name), ".zip") {
+				if unzip {
+					why += ", unpacked"
+				} else {
+					why += ", kept zipped"
+				}
+			}
+			if root != "" && !sysmap.Under(root, f.URL) {
+				why += ", outside root"
+				outside++
+			}
+			preview[i] = fmt.Sprintf("%s  →  %s  [%s]", f.Name, dir, why)
+		}
+		msg := fmt.Sprintf("%d of %d files go into a folder of their own.", sorted, len(files))
+		if outside > 0 {
+			msg += fmt.Sprintf(" %d are outside the root URL.", outside)
+		}
+		if cur.Layout != settings.LayoutSystem {
+			msg += " The folder layout is flat; choose one folder per system in Settings to sort files."
+		}
+		previewStatus.SetText(msg)
+		previewList.Refresh()
+	}
+	profileSelect.OnChanged = func(string) { updatePreview() }
+	presetSelect.OnChanged = func(name string) {
+		for _, p := range presets {
+			if p.Name == name {
+				rows = rows[:0]
+				for _, r := range p.Rules {
+					rows = append(rows, newRuleRow(r))
+				}
+			}
+		}
+		showRows()
+		updatePreview()
+	}
+	previewBtn := widget.NewButton("Update preview", updatePreview)
+	updatePreview()
+
+	previewTitle := fmt.Sprintf("Preview: %d files", len(files))
+	if len(files) == 0 {
+		previewTitle = "Preview: load a listing to see where its files go"
+	}
+	help := widget.NewLabel("Files whose path below the root matches a pattern go into its folder; the first match wins. " +
+		"Folders may use the pattern's groups as $1, and \"/\" for subfolders. Files no rule matches go where the profile " +
+		"puts their system, or else into the folder named after their first path segment below the root.")
+	help.Wrapping = fyne.TextWrapWord
+	rulesPane := container.NewBorder(
+		container.NewVBox(
+			help,
+			container.NewBorder(nil, nil, nil, addBtn, presetSelect),
+		),
+		nil, nil, nil,
+		container.NewVScroll(rulesBox),
+	)
+	previewPane := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, widget.NewLabelWithStyle(previewTitle, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), previewBtn),
+			previewStatus,
+		),
+		nil, nil, nil,
+		previewList,
+	)
+	split := container.NewVSplit(rulesPane, previewPane)
+	content := container.NewBorder(
+		widget.NewForm(
+			widget.NewFormItem("Root URL", container.NewBorder(nil, nil, nil, useLoadedBtn, rootEntry)),
+			widget.NewFormItem("Frontend profile", profileSelect),
+		),
+		nil, nil, nil,
+		split,
+	)
+
+	d := dialog.NewCustom("Folders", "Cancel", content, w)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", d.Hide),
+		widget.NewButton("Save", func() {
+			rs := rules()
+			if _, err := sysmap.New(rs, profile()); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			d.Hide()
+			onSave(strings.TrimSpace(rootEntry.Text), rs, profile())
+		}),
+	})
+	d.Resize(fyne.NewSize(900, 650))
+	d.Show()
+}
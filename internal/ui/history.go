@@ -0,0 +1,58 @@
+// internal/ui/history.go
+package ui
+
+import (
+	"fmt"
+
+	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/jobstore"
+	"awesomeProject1/internal/util"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showHistoryDialog lists finished downloads from the job store, newest first.
+func showHistoryDialog(w fyne.Window, store *jobstore.Store) {
+	records := store.History()
+
+	list := widget.NewList(
+		func() int { return len(records) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(records) {
+				return
+			}
+			r := records[i]
+			text := fmt.Sprintf(
+				"%s  %s — %s (%s)",
+				r.Finished.Format("2006-01-02 15:04"),
+				r.Name,
+				r.State,
+				util.FormatBytes(r.BytesDone, 2),
+			)
+			if r.LastError != "" && r.State != download.JobCancelled.String() {
+				text += ": " + r.LastError
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+
+	d := dialog.NewCustom("Download history", "Close", list, w)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Clear history", func() {
+			if err := store.ClearHistory(); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			records = nil
+			list.Refresh()
+		}),
+		widget.NewButton("Close", d.Hide),
+	})
+	d.Resize(fyne.NewSize(700, 450))
+	d.Show()
+}
@@ -0,0 +1,83 @@
+// internal/ui/mirrors.go
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"awesomeProject1/internal/mirror"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showMirrorsDialog edits the mirror base URLs, one per line, and shows how
+// each mirror has been doing so far.
+func showMirrorsDialog(w fyne.Window, set *mirror.Set, onSaved func(bases []string)) {
+	basesEntry := widget.NewMultiLineEntry()
+	basesEntry.SetPlaceHolder("https://myrient.erista.me/files/\nhttps://mirror.example.org/myrient/")
+	basesEntry.SetText(strings.Join(set.Bases(), "\n"))
+	basesEntry.SetMinRowsVisible(4)
+
+	stats := set.Stats()
+	list := widget.NewList(
+		func() int { return len(stats) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(stats) {
+				return
+			}
+			o.(*widget.Label).SetText(mirrorSummary(stats[i]))
+		},
+	)
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel("Base URLs serving the same tree, one per line. The first is preferred until the others have been measured."),
+			basesEntry,
+			widget.NewLabelWithStyle("Health", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		),
+		nil, nil, nil,
+		list,
+	)
+
+	d := dialog.NewCustom("Mirrors", "Close", content, w)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Save", func() {
+			var bases []string
+			for _, line := range strings.Split(basesEntry.Text, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					bases = append(bases, line)
+				}
+			}
+			set.SetBases(bases)
+			stats = set.Stats()
+			list.Refresh()
+			if onSaved != nil {
+				onSaved(bases)
+			}
+		}),
+		widget.NewButton("Close", d.Hide),
+	})
+	d.Resize(fyne.NewSize(700, 450))
+	d.Show()
+}
+
+// mirrorSummary is one line of mirror health for the list.
+func mirrorSummary(s mirror.Stats) string {
+	if s.Requests == 0 {
+		return s.Base + " — not used yet"
+	}
+	text := fmt.Sprintf(
+		"%s — %d requests, %d failed, %d ms",
+		s.Base, s.Requests, s.Failures, s.Latency.Milliseconds(),
+	)
+	if s.Down {
+		text += " (down, avoided for now)"
+	}
+	return text
+}
@@ -0,0 +1,52 @@
+// internal/ui/organize.go
+package ui
+
+import (
+	"fmt"
+
+	"awesomeProject1/internal/organize"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showOrganizeDialog lists the steps of plan and calls onApply if the user
+// goes ahead.
+func showOrganizeDialog(w fyne.Window, plan *organize.Plan, onApply func()) {
+	lines := plan.Lines()
+	list := widget.NewList(
+		func() int { return len(lines) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(lines) {
+				return
+			}
+			o.(*widget.Label).SetText(lines[i])
+		},
+	)
+
+	top := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Folder: %s", plan.Dir)),
+		widget.NewLabelWithStyle(plan.Summary(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	content := container.NewBorder(top, nil, nil, nil, list)
+
+	d := dialog.NewCustom("Reorganize library", "Cancel", content, w)
+	applyBtn := widget.NewButton("Apply", func() {
+		d.Hide()
+		onApply()
+	})
+	if plan.Empty() {
+		applyBtn.Disable()
+	}
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", d.Hide),
+		applyBtn,
+	})
+	d.Resize(fyne.NewSize(800, 550))
+	d.Show()
+}
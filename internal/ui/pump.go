@@ -0,0 +1,132 @@
+// internal/ui/pump.go
+package ui
+
+import (
+	"sync"
+	"time"
+)
+
+// uiPump is the single path by which background goroutines change the UI:
+// downloads, crawls and index loads hand their updates to it, and it applies
+// them one at a time, in order. Every change is applied from its loop, so
+// that's the one place to hand them to Fyne's main thread with fyne.Do once
+// the app requires Fyne 2.6; Fyne 2.5 has no such call and lets widgets be
+// changed from any goroutine. Until then, state that widget callbacks share
+// with these updates has a lock of its own, as entryList and appState do.
+//
+// Three kinds of change go through it:
+//   - Do runs a function once, after everything requested before it.
+//   - Throttle keeps only the latest function per key and runs it at most
+//     once per interval, for updates that arrive far faster than anyone
+//     can read them (progress, crawl counters).
+//   - Log collects log lines and hands them over in one batch per interval,
+//     so a burst of lines costs one redraw.
+type uiPump struct {
+	interval time.Duration
+	flushLog func(lines []string)
+
+	calls chan func()
+	wake  chan struct{}
+
+	mu      sync.Mutex
+	lines   []string
+	pending map[string]func()
+	order   []string // keys of pending, first request first
+}
+
+// newUIPump starts a pump that applies throttled updates and log batches
+// every interval, passing log lines to flushLog.
+func newUIPump(interval time.Duration, flushLog func(lines []string)) *uiPump {
+	p := &uiPump{
+		interval: interval,
+		flushLog: flushLog,
+		calls:    make(chan func(), 256),
+		wake:     make(chan struct{}, 1),
+		pending:  map[string]func(){},
+	}
+	go p.run()
+	return p
+}
+
+// Do queues f to run on the pump.
+func (p *uiPump) Do(f func()) {
+	p.calls <- f
+}
+
+// Throttle queues f to run on the pump's next tick, replacing anything
+// still queued under the same key.
+func (p *uiPump) Throttle(key string, f func()) {
+	p.mu.Lock()
+	if _, ok := p.pending[key]; !ok {
+		p.order = append(p.order, key)
+	}
+	p.pending[key] = f
+	p.mu.Unlock()
+	p.poke()
+}
+
+// Log queues a log line for the next batch. It never blocks.
+func (p *uiPump) Log(line string) {
+	p.mu.Lock()
+	p.lines = append(p.lines, line)
+	p.mu.Unlock()
+	p.poke()
+}
+
+func (p *uiPump) poke() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *uiPump) run() {
+	var last time.Time
+	var timer <-chan time.Time
+	for {
+		select {
+		case f := <-p.calls:
+			// Whatever was requested before f lands before it, so a
+			// final status isn't overwritten by a stale throttled one.
+			p.flush()
+			f()
+			continue
+		case <-p.wake:
+			if timer != nil {
+				continue
+			}
+			// Apply straight away after a quiet spell, otherwise wait out
+			// the rest of the interval so bursts are coalesced.
+			wait := p.interval - time.Since(last)
+			if wait > 0 {
+				timer = time.After(wait)
+				continue
+			}
+		case <-timer:
+		}
+		timer = nil
+		last = time.Now()
+		p.flush()
+	}
+}
+
+// flush applies everything collected since the last tick.
+func (p *uiPump) flush() {
+	p.mu.Lock()
+	lines := p.lines
+	p.lines = nil
+	updates := make([]func(), 0, len(p.order))
+	for _, key := range p.order {
+		updates = append(updates, p.pending[key])
+	}
+	p.order = p.order[:0]
+	clear(p.pending)
+	p.mu.Unlock()
+
+	if len(lines) > 0 {
+		p.flushLog(lines)
+	}
+	for _, f := range updates {
+		f()
+	}
+}
@@ -0,0 +1,240 @@
+// internal/ui/queue.go
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"awesomeProject1/internal/download"
+	"awesomeProject1/internal/util"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// queueColumn is one column of the queue table.
+type queueColumn struct {
+	title string
+	width float32
+	text  func(j *download.Job, system string) string
+}
+
+// queueColumns are the queue table's columns in display order. The row
+// buttons go in an extra column after them.
+var queueColumns = []queueColumn{
+	{"Name", 260, func(j *download.Job, _ string) string { return j.Name }},
+	{"System", 150, func(_ *download.Job, system string) string { return system }},
+	{"State", 80, func(j *download.Job, _ string) string { return j.State().String() }},
+	{"Bytes", 150, jobBytes},
+	{"Speed", 90, jobSpeed},
+	{"ETA", 90, jobETA},
+	{"Tries", 50, func(j *download.Job, _ string) string { return fmt.Sprint(j.Attempts()) }},
+	{"Error", 220, jobError},
+}
+
+// queueActionsWidth fits the Pause, Cancel, Retry and Folder buttons.
+const queueActionsWidth = 330
+
+// queueTable lists every job in a download queue, finished ones included,
+// with buttons to pause, cancel or retry a job and to open its folder.
+type queueTable struct {
+	*widget.Table
+
+	queue *download.Queue
+
+	// onError reports a folder that can't be opened.
+	onError func(error)
+
+	// mu guards the snapshot the table draws from; Refresh replaces it on
+	// the UI pump while Fyne draws rows on its own goroutine.
+	mu      sync.Mutex
+	jobs    []*download.Job
+	systems map[int]string // job ID -> system folder name
+}
+
+func newQueueTable(q *download.Queue, onError func(error)) *queueTable {
+	t := &queueTable{queue: q, onError: onError, systems: map[int]string{}}
+	t.Table = widget.NewTableWithHeaders(
+		func() (int, int) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return len(t.jobs), len(queueColumns) + 1
+		},
+		t.createCell,
+		t.updateCell,
+	)
+	t.ShowHeaderColumn = false
+	t.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	t.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		title := ""
+		if id.Col >= 0 && id.Col < len(queueColumns) {
+			title = queueColumns[id.Col].title
+		}
+		o.(*widget.Label).SetText(title)
+	}
+	for i, c := range queueColumns {
+		t.SetColumnWidth(i, c.width)
+	}
+	t.SetColumnWidth(len(queueColumns), queueActionsWidth)
+	return t
+}
+
+// SetSystem records the system j's file was sorted into, for its row.
+func (t *queueTable) SetSystem(j *download.Job, system string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.systems[j.ID] = system
+}
+
+// Refresh takes a fresh snapshot of the queue and redraws the table.
+func (t *queueTable) Refresh() {
+	jobs := t.queue.Jobs()
+	t.mu.Lock()
+	t.jobs = jobs
+	t.mu.Unlock()
+	t.Table.Refresh()
+}
+
+// createCell returns a cell that can be either a text column or the button
+// column; updateCell shows the half it needs.
+func (t *queueTable) createCell() fyne.CanvasObject {
+	lbl := widget.NewLabel("")
+	lbl.Truncation = fyne.TextTruncateEllipsis
+	buttons := container.NewHBox(
+		widget.NewButton("Pause", nil),
+		widget.NewButton("Cancel", nil),
+		widget.NewButton("Retry", nil),
+		widget.NewButton("Folder", nil),
+	)
+	return container.NewStack(lbl, buttons)
+}
+
+func (t *queueTable) updateCell(id widget.TableCellID, o fyne.CanvasObject) {
+	t.mu.Lock()
+	if id.Row < 0 || id.Row >= len(t.jobs) {
+		t.mu.Unlock()
+		return
+	}
+	j := t.jobs[id.Row]
+	system := t.systems[j.ID]
+	t.mu.Unlock()
+
+	cell := o.(*fyne.Container)
+	lbl := cell.Objects[0].(*widget.Label)
+	buttons := cell.Objects[1].(*fyne.Container)
+
+	if id.Col < len(queueColumns) {
+		buttons.Hide()
+		lbl.Show()
+		lbl.SetText(queueColumns[id.Col].text(j, system))
+		return
+	}
+	lbl.Hide()
+	buttons.Show()
+	t.updateActions(j, buttons)
+}
+
+// updateActions points a row's buttons at j and enables those that apply
+// to its current state.
+func (t *queueTable) updateActions(j *download.Job, buttons *fyne.Container) {
+	pauseBtn := buttons.Objects[0].(*widget.Button)
+	cancelBtn := buttons.Objects[1].(*widget.Button)
+	retryBtn := buttons.Objects[2].(*widget.Button)
+	folderBtn := buttons.Objects[3].(*widget.Button)
+
+	st := j.State()
+	if st == download.JobPaused {
+		pauseBtn.SetText("Resume")
+		pauseBtn.OnTapped = func() { t.queue.Resume(j) }
+	} else {
+		pauseBtn.SetText("Pause")
+		pauseBtn.OnTapped = func() { t.queue.Pause(j) }
+	}
+	cancelBtn.OnTapped = func() { t.queue.Cancel(j) }
+	retryBtn.OnTapped = func() { t.queue.Retry(j) }
+	folderBtn.OnTapped = func() {
+		if err := openFolder(j.TargetDir); err != nil {
+			t.onError(err)
+		}
+	}
+
+	setEnabled(pauseBtn, !st.Finished())
+	setEnabled(cancelBtn, !st.Finished())
+	setEnabled(retryBtn, st == download.JobFailed || st == download.JobCancelled)
+}
+
+func setEnabled(b *widget.Button, on bool) {
+	if on {
+		b.Enable()
+	} else {
+		b.Disable()
+	}
+}
+
+// openFolder shows dir in the system file manager.
+func openFolder(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("folder not available: %w", err)
+	}
+	u, err := url.Parse(storage.NewFileURI(dir).String())
+	if err != nil {
+		return err
+	}
+	return fyne.CurrentApp().OpenURL(u)
+}
+
+// jobBytes is the bytes done, out of the total once it's known.
+func jobBytes(j *download.Job, _ string) string {
+	p := j.Progress()
+	if p.BytesTotal > 0 {
+		return util.FormatBytes(p.BytesDone, 2) + " / " + util.FormatBytes(p.BytesTotal, 2)
+	}
+	if p.BytesDone > 0 {
+		return util.FormatBytes(p.BytesDone, 2)
+	}
+	return ""
+}
+
+func jobSpeed(j *download.Job, _ string) string {
+	if j.State() != download.JobRunning {
+		return ""
+	}
+	if p := j.Progress(); p.Speed > 0 && p.NextRetry.IsZero() {
+		return util.FormatBytes(p.Speed, 1) + "/s"
+	}
+	return ""
+}
+
+func jobETA(j *download.Job, _ string) string {
+	if j.State() != download.JobRunning {
+		return ""
+	}
+	p := j.Progress()
+	if !p.NextRetry.IsZero() {
+		return "retry in " + util.FormatDuration(time.Until(p.NextRetry))
+	}
+	return p.ETA
+}
+
+// jobError is a failed job's error, or the last attempt's error while a
+// retry is pending.
+func jobError(j *download.Job, _ string) string {
+	switch j.State() {
+	case download.JobFailed:
+		if err := j.Err(); err != nil {
+			return err.Error()
+		}
+	case download.JobRunning:
+		if p := j.Progress(); !p.NextRetry.IsZero() && p.Err != nil {
+			return p.Err.Error()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+// internal/ui/selection.go
+package ui
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"awesomeProject1/internal/selection"
+	"awesomeProject1/internal/util"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// saveSelectionFile asks for a name for l and a file to save it in. A .txt
+// file gets a plain URL list, anything else JSON.
+func saveSelectionFile(w fyne.Window, l *selection.List) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. All USA NES games")
+	items := []*widget.FormItem{widget.NewFormItem("List name", nameEntry)}
+
+	dialog.ShowForm(fmt.Sprintf("Save %d selected files", len(l.Entries)), "Save…", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		l.Name = strings.TrimSpace(nameEntry.Text)
+
+		fd := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+			if err != nil || wc == nil {
+				return
+			}
+			defer wc.Close()
+			write := l.WriteJSON
+			if strings.EqualFold(wc.URI().Extension(), ".txt") {
+				write = l.WriteText
+			}
+			if err := write(wc); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}, w)
+		fd.SetFileName(selectionFileName(l.Name))
+		fd.Show()
+	}, w)
+}
+
+// selectionFileName suggests a file name for a list called name.
+func selectionFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "selection"
+	}
+	return name + ".json"
+}
+
+// openSelectionFile asks for a saved list and passes it to onLoad.
+func openSelectionFile(w fyne.Window, onLoad func(*selection.List)) {
+	fd := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+		if err != nil || rc == nil {
+			return
+		}
+		defer rc.Close()
+		l, err := readSelection(rc, rc.URI().Name())
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		onLoad(l)
+	}, w)
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".json", ".txt"}))
+	fd.Show()
+}
+
+func readSelection(r io.Reader, name string) (*selection.List, error) {
+	l, err := selection.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if l.Name == "" {
+		l.Name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return l, nil
+}
+
+// showMissingEntries lists the entries of l that aren't in the loaded
+// index.
+func showMissingEntries(w fyne.Window, l *selection.List, res selection.Result) {
+	missing := res.Missing
+	list := widget.NewList(
+		func() int { return len(missing) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(missing) {
+				return
+			}
+			e := missing[i]
+			text := e.Name
+			if e.System != "" {
+				text += "  [" + e.System + "]"
+			}
+			if e.Size > 0 {
+				text += "  " + util.FormatBytes(e.Size, 2)
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+
+	title := fmt.Sprintf("%s: %d of %d files not found", l.Name, len(missing), len(l.Entries))
+	d := dialog.NewCustom(title, "Close", list, w)
+	d.Resize(fyne.NewSize(600, 400))
+	d.Show()
+}
@@ -0,0 +1,208 @@
+// internal/ui/settings.go
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/sysmap"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Preference keys; they match the TOML keys of the settings file.
+const (
+	prefDownloadDir = "download_dir"
+	prefConcurrency = "concurrency"
+	prefConnections = "connections"
+	prefRetries     = "retries"
+	prefRateLimit   = "rate_limit"
+	prefExtract     = "extract"
+	prefLayout      = "layout"
+	prefRootURL     = "root_url"
+	prefRules       = "rules"
+	prefProfile     = "profile"
+	prefMirrors     = "mirrors"
+	prefLastURL     = "last_url"
+)
+
+// loadPreferences reads the settings from the app's preferences, falling
+// back to the defaults for anything unset or out of range.
+func loadPreferences(p fyne.Preferences) settings.Settings {
+	def := settings.Defaults()
+	s := settings.Settings{
+		DownloadDir: p.StringWithFallback(prefDownloadDir, def.DownloadDir),
+		Concurrency: p.IntWithFallback(prefConcurrency, def.Concurrency),
+		Connections: p.IntWithFallback(prefConnections, def.Connections),
+		Retries:     p.IntWithFallback(prefRetries, def.Retries),
+		RateLimit:   p.StringWithFallback(prefRateLimit, def.RateLimit),
+		Extract:     p.BoolWithFallback(prefExtract, def.Extract),
+		Layout:      p.StringWithFallback(prefLayout, def.Layout),
+		RootURL:     p.StringWithFallback(prefRootURL, def.RootURL),
+		Mirrors:     p.StringListWithFallback(prefMirrors, def.Mirrors),
+		LastURL:     p.StringWithFallback(prefLastURL, def.LastURL),
+		Profile:     p.StringWithFallback(prefProfile, def.Profile),
+	}
+	// Rules are kept as JSON, as preferences have no list of pairs.
+	if text := p.String(prefRules); text != "" && json.Unmarshal([]byte(text), &s.Rules) != nil {
+		s.Rules = nil
+	}
+	if s.Validate() != nil {
+		// A hand-edited or older preferences file; keep what's usable.
+		fixed := def
+		fixed.DownloadDir, fixed.RootURL, fixed.Mirrors, fixed.LastURL = s.DownloadDir, s.RootURL, s.Mirrors, s.LastURL
+		if _, err := sysmap.New(s.Rules, s.Profile); err == nil {
+			fixed.Rules, fixed.Profile = s.Rules, s.Profile
+		}
+		return fixed
+	}
+	return s
+}
+
+// savePreferences stores s in the app's preferences.
+func savePreferences(p fyne.Preferences, s settings.Settings) {
+	p.SetString(prefDownloadDir, s.DownloadDir)
+	p.SetInt(prefConcurrency, s.Concurrency)
+	p.SetInt(prefConnections, s.Connections)
+	p.SetInt(prefRetries, s.Retries)
+	p.SetString(prefRateLimit, s.RateLimit)
+	p.SetBool(prefExtract, s.Extract)
+	p.SetString(prefLayout, s.Layout)
+	p.SetString(prefRootURL, s.RootURL)
+	p.SetStringList(prefMirrors, s.Mirrors)
+	p.SetString(prefLastURL, s.LastURL)
+	rules := ""
+	if len(s.Rules) > 0 {
+		b, _ := json.Marshal(s.Rules)
+		rules = string(b)
+	}
+	p.SetString(prefRules, rules)
+	p.SetString(prefProfile, s.Profile)
+}
+
+// rateLimitFields splits a rate in bytes per second into the bandwidth
+// entry's text and unit, preferring MB/s when it's a whole number of them.
+func rateLimitFields(bps int64) (text, unit string) {
+	switch {
+	case bps == 0:
+		return "", "MB/s"
+	case bps%(1<<20) == 0:
+		return strconv.FormatInt(bps>>20, 10), "MB/s"
+	default:
+		return strconv.FormatFloat(float64(bps)/1024, 'f', -1, 64), "KB/s"
+	}
+}
+
+// showSettingsDialog edits cur and passes the result to onSave once it
+// validates.
+func showSettingsDialog(w fyne.Window, cur settings.Settings, onSave func(settings.Settings)) {
+	dirEntry := widget.NewEntry()
+	dirEntry.SetText(cur.DownloadDir)
+	dirEntry.SetPlaceHolder("not set")
+	browseBtn := widget.NewButton("Browse…", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				dirEntry.SetText(uri.Path())
+			}
+		}, w)
+	})
+
+	intEntry := func(v int) *widget.Entry {
+		e := widget.NewEntry()
+		e.SetText(strconv.Itoa(v))
+		return e
+	}
+	concurrencyEntry := intEntry(cur.Concurrency)
+	connectionsEntry := intEntry(cur.Connections)
+	retriesEntry := intEntry(cur.Retries)
+	retriesEntry.SetPlaceHolder("0 = default")
+
+	limitEntry := widget.NewEntry()
+	limitEntry.SetText(cur.RateLimit)
+	limitEntry.SetPlaceHolder("e.g. 500K or 2M; empty = unlimited")
+
+	extractCheck := widget.NewCheck("Unpack downloaded .zip files and delete them", nil)
+	extractCheck.SetChecked(cur.Extract)
+
+	layoutNames := map[string]string{
+		settings.LayoutSystem: "One folder per system",
+		settings.LayoutFlat:   "Everything in the download folder",
+	}
+	layoutSelect := widget.NewSelect([]string{layoutNames[settings.LayoutSystem], layoutNames[settings.LayoutFlat]}, nil)
+	layoutSelect.SetSelected(layoutNames[cur.Layout])
+
+	rootEntry := widget.NewEntry()
+	rootEntry.SetText(cur.RootURL)
+	rootEntry.SetPlaceHolder("empty = the URL loaded")
+
+	mirrorsEntry := widget.NewMultiLineEntry()
+	mirrorsEntry.SetText(strings.Join(cur.Mirrors, "\n"))
+	mirrorsEntry.SetPlaceHolder("one base URL per line")
+	mirrorsEntry.SetMinRowsVisible(3)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Download folder", container.NewBorder(nil, nil, nil, browseBtn, dirEntry)),
+		widget.NewFormItem("Concurrent downloads", concurrencyEntry),
+		widget.NewFormItem("Connections per file", connectionsEntry),
+		widget.NewFormItem("Attempts per file", retriesEntry),
+		widget.NewFormItem("Bandwidth limit", limitEntry),
+		widget.NewFormItem("Archives", extractCheck),
+		widget.NewFormItem("Folder layout", layoutSelect),
+		widget.NewFormItem("Root URL", rootEntry),
+		widget.NewFormItem("Mirrors", mirrorsEntry),
+	)
+
+	d := dialog.NewCustom("Settings", "Cancel", form, w)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", d.Hide),
+		widget.NewButton("Save", func() {
+			s := cur
+			s.DownloadDir = strings.TrimSpace(dirEntry.Text)
+			s.RateLimit = strings.TrimSpace(limitEntry.Text)
+			s.Extract = extractCheck.Checked
+			s.RootURL = strings.TrimSpace(rootEntry.Text)
+			s.Mirrors = nil
+			for _, line := range strings.Split(mirrorsEntry.Text, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					s.Mirrors = append(s.Mirrors, line)
+				}
+			}
+			for key, name := range layoutNames {
+				if name == layoutSelect.Selected {
+					s.Layout = key
+				}
+			}
+
+			for _, f := range []struct {
+				name  string
+				entry *widget.Entry
+				dst   *int
+			}{
+				{"Concurrent downloads", concurrencyEntry, &s.Concurrency},
+				{"Connections per file", connectionsEntry, &s.Connections},
+				{"Attempts per file", retriesEntry, &s.Retries},
+			} {
+				n, err := strconv.Atoi(strings.TrimSpace(f.entry.Text))
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("%s: %q is not a number", f.name, f.entry.Text), w)
+					return
+				}
+				*f.dst = n
+			}
+			if err := s.Validate(); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			d.Hide()
+			onSave(s)
+		}),
+	})
+	d.Resize(fyne.NewSize(600, 0))
+	d.Show()
+}
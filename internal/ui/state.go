@@ -0,0 +1,92 @@
+// internal/ui/state.go
+package ui
+
+import (
+	"sync"
+
+	"awesomeProject1/internal/settings"
+	"awesomeProject1/internal/sysmap"
+)
+
+// appState is the settings and where the listing came from. Widget
+// callbacks read and change them on Fyne's goroutines while loads finish on
+// the UI pump, so every access goes through its lock.
+type appState struct {
+	mu   sync.Mutex
+	conf settings.Settings
+
+	// listRoot is the URL the listing came from. System folders are named
+	// relative to the configured root, or this one if there is none.
+	listRoot string
+
+	// folders applies conf's folder rules and profile.
+	folders *sysmap.Mapper
+}
+
+// newAppState starts from conf, which was validated, so its rules compile.
+func newAppState(conf settings.Settings) *appState {
+	folders, _ := sysmap.New(conf.Rules, conf.Profile)
+	return &appState{conf: conf, folders: folders}
+}
+
+// Conf returns a copy of the settings.
+func (s *appState) Conf() settings.Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conf
+}
+
+// Update changes the settings with f. f must leave the folder rules and
+// profile as they are; SetFolders changes those.
+func (s *appState) Update(f func(c *settings.Settings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(&s.conf)
+}
+
+// SetFolders sets the root URL, folder rules and profile, unless the rules
+// don't compile.
+func (s *appState) SetFolders(root string, rules []sysmap.Rule, profile string) (*sysmap.Mapper, error) {
+	m, err := sysmap.New(rules, profile)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conf.RootURL, s.conf.Rules, s.conf.Profile = root, rules, profile
+	s.folders = m
+	return m, nil
+}
+
+// Folders returns the current folder rules and profile.
+func (s *appState) Folders() *sysmap.Mapper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.folders
+}
+
+// SetListRoot records the URL the listing came from.
+func (s *appState) SetListRoot(u string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listRoot = u
+}
+
+// ListRoot returns the URL the listing came from, "" before any.
+func (s *appState) ListRoot() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listRoot
+}
+
+// Folder returns the folder, slash separated, that the file at fileURL
+// belongs in, "" if none, and whether the layout sorts files into them.
+func (s *appState) Folder(fileURL string) (folder string, sorted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	root := s.conf.RootURL
+	if root == "" {
+		root = s.listRoot
+	}
+	return s.folders.Folder(root, fileURL), s.conf.Layout == settings.LayoutSystem
+}
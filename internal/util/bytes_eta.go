@@ -43,7 +43,22 @@ func CalculateETA(current, total int64, startTime time.Time) string {
 		remaining = 0
 	}
 
-	sec := int(remaining.Seconds())
+	return FormatDuration(remaining)
+}
+
+// ETAFromRate estimates remaining time for the given number of bytes at a
+// measured rate in bytes per second. Unlike CalculateETA it reacts to speed
+// changes, e.g. when a bandwidth limit is raised or lowered mid-transfer.
+func ETAFromRate(remaining int64, bytesPerSec float64) string {
+	if remaining <= 0 || bytesPerSec <= 0 {
+		return "--"
+	}
+	return FormatDuration(time.Duration(float64(remaining) / bytesPerSec * float64(time.Second)))
+}
+
+// FormatDuration renders d as e.g. "1h 5m 3s", dropping zero units.
+func FormatDuration(d time.Duration) string {
+	sec := int(d.Seconds())
 	min := sec / 60
 	hr := min / 60
 
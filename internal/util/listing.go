@@ -0,0 +1,74 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnits maps the unit suffixes used by directory listings to their
+// multipliers. Index pages mean powers of 1024 even when they write "KB".
+var sizeUnits = map[string]float64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+	"p": 1 << 50, "pb": 1 << 50, "pib": 1 << 50,
+}
+
+// ParseSize parses a size column such as "1.2 GiB", "512K" or "1234" into
+// bytes. It reports false for "-" (directories) and anything unrecognised.
+func ParseSize(s string) (int64, bool) {
+	s = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ",", ""))
+	if s == "" || s == "-" {
+		return 0, false
+	}
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	num, unit := s, ""
+	if i >= 0 {
+		num, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+	mult, ok := sizeUnits[unit]
+	if !ok || num == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return int64(v * mult), true
+}
+
+// listingDateLayouts are the date formats written by common autoindex
+// modules, most common first.
+var listingDateLayouts = []string{
+	"02-Jan-2006 15:04",    // nginx, Myrient
+	"02-Jan-2006 15:04:05", // nginx with exact times
+	"2006-01-02 15:04",     // Apache fancy index
+	"2006-01-02 15:04:05",
+	"2006-Jan-02 15:04:05", // lighttpd
+	"2006-Jan-02 15:04",
+	time.RFC1123, // nginx JSON autoindex
+	time.RFC3339, // Caddy
+	"02-Jan-2006",
+	"2006-01-02",
+}
+
+// ParseListingDate parses a date column from a directory listing. Times
+// without a zone are taken as UTC, which is what the servers print.
+func ParseListingDate(s string) (time.Time, bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" || s == "-" {
+		return time.Time{}, false
+	}
+	for _, layout := range listingDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
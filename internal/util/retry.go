@@ -0,0 +1,196 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed request is tried again.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, the first included.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry; it doubles with every
+	// further retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Jitter randomly shortens each wait by up to this fraction (0..1), so
+	// many failed jobs don't all come back at the same moment.
+	Jitter float64
+
+	// RetryStatus lists the HTTP status codes worth retrying. Any other
+	// status fails straight away.
+	RetryStatus []int
+
+	// RetryNetwork retries connection failures, resets and timeouts.
+	RetryNetwork bool
+}
+
+// DefaultRetryPolicy retries 5 times over roughly a minute, on network
+// errors and on the statuses that mean "try again later".
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    time.Minute,
+		Jitter:      0.5,
+		RetryStatus: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooEarly,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetwork: true,
+	}
+}
+
+// HTTPStatusError is an unexpected HTTP response status.
+type HTTPStatusError struct {
+	Code   int
+	Status string
+
+	// RetryAfter is the wait the server asked for in a Retry-After header,
+	// 0 if none.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return "http status: " + e.Status
+}
+
+// NewHTTPStatusError describes resp's status, including its Retry-After.
+func NewHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	return &HTTPStatusError{
+		Code:       resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter reads either form of Retry-After: seconds or a date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying under any policy.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// Retryable reports whether err is worth another try. Errors the policy
+// has no opinion on, such as a checksum mismatch, are retried; cancellation,
+// local file errors and errors marked Permanent are not.
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var perm permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	var se *HTTPStatusError
+	if errors.As(err, &se) {
+		return slices.Contains(p.RetryStatus, se.Code)
+	}
+	var ne net.Error
+	if errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return p.RetryNetwork
+	}
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		// Disk full, permission denied and the like won't fix themselves.
+		return false
+	}
+	return true
+}
+
+// Delay returns how long to wait after the given failed attempt (1-based).
+// A Retry-After from the server is honoured when it asks for longer.
+func (p RetryPolicy) Delay(attempt int, err error) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+
+	var se *HTTPStatusError
+	if errors.As(err, &se) && se.RetryAfter > d {
+		d = se.RetryAfter
+	}
+	return d
+}
+
+// Do calls fn until it succeeds, the policy gives up or ctx is done.
+// Before each wait onRetry, if set, is told which attempt failed, why and
+// when the next one starts. If ctx ends during a wait, its cause is
+// returned; otherwise the last error from fn.
+func (p RetryPolicy) Do(ctx context.Context, fn func(attempt int) error, onRetry func(attempt int, next time.Time, err error)) error {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || ctx.Err() != nil || attempt >= maxAttempts || !p.Retryable(err) {
+			return err
+		}
+
+		d := p.Delay(attempt, err)
+		if onRetry != nil {
+			onRetry(attempt, time.Now().Add(d), err)
+		}
+		if werr := SleepCtx(ctx, d); werr != nil {
+			return werr
+		}
+	}
+}
+
+// SleepCtx waits for d or until ctx is done, returning the context's cause
+// in the latter case.
+func SleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return context.Cause(ctx)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -0,0 +1,51 @@
+// internal/util/zipinfo.go
+package util
+
+import (
+	"archive/zip"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ZipMember describes one file inside a zip as recorded in its central
+// directory.
+type ZipMember struct {
+	Name  string
+	Size  int64
+	CRC32 uint32
+}
+
+// ZipMembers lists the files in a zip without extracting anything. The
+// CRC32 values come straight from the central directory, so this is cheap
+// even for multi-gigabyte archives.
+func ZipMembers(zipPath string) ([]ZipMember, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+	defer r.Close()
+
+	var out []ZipMember
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		out = append(out, ZipMember{
+			Name:  f.Name,
+			Size:  int64(f.UncompressedSize64),
+			CRC32: f.CRC32,
+		})
+	}
+	return out, nil
+}
+
+// IsArchiveName reports whether name looks like an archive holding a game's
+// files rather than the files themselves.
+func IsArchiveName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".zip", ".7z", ".rar":
+		return true
+	}
+	return false
+}
@@ -1,7 +1,16 @@
 package main
 
-import "awesomeProject1/internal/ui"
+import (
+	"os"
+
+	"awesomeProject1/internal/cli"
+	"awesomeProject1/internal/ui"
+)
 
 func main() {
+	// Any argument means command-line use; without one the GUI starts.
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
 	ui.Run()
 }